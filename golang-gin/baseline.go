@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// workload is a canned request against one of the benchmark endpoints.
+type workload struct {
+	Name   string
+	Method string
+	Path   string
+	Body   string
+}
+
+// Standard text used for the string workloads (about 10KB)
+var standardText = strings.Repeat("The quick brown fox jumps over the lazy dog. ", 230)
+
+// standardWorkloads exercises every workload once with typical inputs.
+var standardWorkloads = []workload{
+	{"hello", http.MethodGet, "/", ""},
+	{"normal", http.MethodPost, "/process/normal", `{"name":"Ada Lovelace","birthdate":"1990-12-10","email":"ada@example.com","data":{"k":"v"}}`},
+	{"normal-batch", http.MethodPost, "/process/normal/batch", `[{"name":"Ada Lovelace","birthdate":"1990-12-10","email":"ada@example.com"},{"name":"Alan Turing","birthdate":"1912-06-23","email":"alan@example.com"}]`},
+	{"memory", http.MethodPost, "/process/memory", `{"size_mb":10,"iterations":10}`},
+	{"parallel", http.MethodPost, "/process/parallel", `{"workers":4,"n":10000}`},
+	{"io", http.MethodPost, "/process/io", `{"delay_ms":10}`},
+	{"json", http.MethodPost, "/process/json", `{"count":1000}`},
+	{"sha256", http.MethodPost, "/process/hash", `{"algorithm":"sha256","text":"` + standardText + `"}`},
+	{"bcrypt", http.MethodPost, "/process/hash", `{"algorithm":"bcrypt","text":"correct horse battery staple"}`},
+	{"matrix", http.MethodPost, "/process/matrix", `{"size":128}`},
+	{"sort", http.MethodPost, "/process/sort", `{"count":100000}`},
+	{"primes", http.MethodPost, "/process/primes", `{"budget_ms":50}`},
+	{"cpu-intensive", http.MethodPost, "/process/cpu-intensive", `{"n":35}`},
+	{"atkin", http.MethodPost, "/process/cpu-intensive?algo=atkin&limit=1000000", `{"n":20}`},
+	{"fft", http.MethodPost, "/process/cpu-intensive?func=fft", ""},
+	{"catalan", http.MethodPost, "/process/cpu-intensive?func=catalan", ""},
+	{"sudoku", http.MethodPost, "/process/cpu-intensive?func=sudoku", ""},
+	{"perfect", http.MethodPost, "/process/cpu-intensive?func=perfect", ""},
+	{"josephus", http.MethodPost, "/process/cpu-intensive?func=josephus", ""},
+	{"pascal", http.MethodPost, "/process/cpu-intensive?func=pascal", ""},
+	{"hanoi", http.MethodPost, "/process/cpu-intensive?func=hanoi&moves=true", ""},
+	{"dlog", http.MethodPost, "/process/cpu-intensive?func=dlog", ""},
+	{"totient", http.MethodPost, "/process/cpu-intensive?func=totient", ""},
+	{"factorial", http.MethodPost, "/process/cpu-intensive?func=factorial", ""},
+	{"contfrac", http.MethodPost, "/process/cpu-intensive?func=contfrac", ""},
+	{"convexhull", http.MethodPost, "/process/cpu-intensive?func=convexhull", ""},
+	{"lucas", http.MethodPost, "/process/cpu-intensive?func=lucas&n=100000", ""},
+	{"markov", http.MethodPost, "/process/cpu-intensive?func=markov", ""},
+	{"reverse", http.MethodPost, "/process/strings", stringWorkloadBody("reverse", standardText)},
+	{"uppercase", http.MethodPost, "/process/strings", stringWorkloadBody("uppercase", standardText)},
+	{"count", http.MethodPost, "/process/strings", stringWorkloadBody("count", standardText)},
+	{"pattern", http.MethodPost, "/process/strings", stringWorkloadBody("pattern", standardText)},
+	{"concatenate", http.MethodPost, "/process/strings", stringWorkloadBody("concatenate", standardText)},
+	{"luhn", http.MethodPost, "/process/strings", stringWorkloadBody("luhn", "4539 1488 0343 6467")},
+	{"csv_stats", http.MethodPost, "/process/strings", stringWorkloadBody("csv_stats", "id,name,score\n1,ada,90.5\n2,alan,85\n3,grace,99")},
+	{"wordbreak", http.MethodPost, "/process/strings", stringWorkloadBody("wordbreak", "pineapplepenapple")},
+	{"script_detect", http.MethodPost, "/process/strings", stringWorkloadBody("script_detect", "Hello мир, 你好世界! مرحبا")},
+	{"multisort", http.MethodPost, "/process/strings", stringWorkloadBody("multisort", standardText)},
+	{"spellcheck", http.MethodPost, "/process/strings", stringWorkloadBody("spellcheck", "The quikc brwn fox jumsp ovr the lazzy dog")},
+	{"readability", http.MethodPost, "/process/strings", stringWorkloadBody("readability", standardText)},
+	{"fuzzy_match", http.MethodPost, "/process/strings", `{"operation":"fuzzy_match","text":"the quick brown fox jumps over the lazy dog","pattern":"quack"}`},
+	{"runs", http.MethodPost, "/process/strings", stringWorkloadBody("runs", standardText)},
+	{"balance", http.MethodPost, "/process/strings", stringWorkloadBody("balance", strings.Repeat("{[()()]}", 1000))},
+	{"simhash", http.MethodPost, "/process/strings", stringWorkloadBody("simhash", standardText)},
+	{"tfidf", http.MethodPost, "/process/strings", stringWorkloadBody("tfidf", "the cat sat on the mat\n\nthe dog chased the cat\n\ndogs and cats and mats")},
+	{"suffix_automaton", http.MethodPost, "/process/strings", stringWorkloadBody("suffix_automaton", standardText)},
+	{"checksum", http.MethodPost, "/process/strings", stringWorkloadBody("checksum", standardText)},
+	{"base64", http.MethodPost, "/process/strings", stringWorkloadBody("base64", standardText)},
+	{"titlecase", http.MethodPost, "/process/strings", stringWorkloadBody("titlecase", standardText)},
+	{"rot13", http.MethodPost, "/process/strings", stringWorkloadBody("rot13", standardText)},
+	{"palindrome_check", http.MethodPost, "/process/strings", stringWorkloadBody("palindrome_check", "Ésope reste ici et se reposé")},
+	{"regex", http.MethodPost, "/process/strings", `{"operation":"regex","pattern":"\\b[a-z]{4,5}\\b","text":"` + standardText + `"}`},
+	{"boyermoore", http.MethodPost, "/process/strings", `{"operation":"boyermoore","pattern":"lazy dog","text":"` + standardText + `"}`},
+	{"jaccard", http.MethodPost, "/process/strings", `{"operation":"jaccard","text":"the quick brown fox","text2":"the quick brown dog"}`},
+}
+
+func stringWorkloadBody(operation, text string) string {
+	body, _ := json.Marshal(gin.H{"operation": operation, "text": text})
+	return string(body)
+}
+
+// baselineResult is the outcome of running one workload at startup.
+type baselineResult struct {
+	Name           string  `json:"name"`
+	Status         int     `json:"status"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+// newReplayRouter serves the workload routes with none of the server's
+// middleware, so in-process replays measure the handlers alone and don't
+// pass through auth, rate limiting, the CPU worker pool, compression,
+// logging or the request metrics.
+func newReplayRouter() *gin.Engine {
+	r := gin.New()
+	// A pool of 0 workers is no pool at all
+	registerWorkloads(r, limitCPUWorkers(0, ""))
+	return r
+}
+
+// runWorkload sends w through the router in-process and returns the
+// response status and elapsed wall-clock time.
+func runWorkload(handler http.Handler, w workload) (int, time.Duration) {
+	req := httptest.NewRequest(w.Method, w.Path, strings.NewReader(w.Body))
+	if w.Body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	recorder := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(recorder, req)
+	return recorder.Code, time.Since(start)
+}
+
+// runBaseline runs every standard workload once, logs the timings and
+// records them for /config.
+func runBaseline(handler http.Handler) {
+	results := make([]baselineResult, 0, len(standardWorkloads))
+	for _, w := range standardWorkloads {
+		status, elapsed := runWorkload(handler, w)
+		if status != http.StatusOK {
+			logf("warn", "baseline: %s returned status %d", w.Name, status)
+		}
+		logf("info", "baseline: %s took %s", w.Name, elapsed)
+		results = append(results, baselineResult{Name: w.Name, Status: status, ElapsedSeconds: elapsed.Seconds()})
+	}
+
+	config.mu.Lock()
+	config.baseline = results
+	config.mu.Unlock()
+}
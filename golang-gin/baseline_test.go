@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRunBaseline(t *testing.T) {
+	setConfig(t, &config.baseline, nil)
+
+	tests := []struct {
+		name      string
+		workloads []workload
+		status    []int
+	}{
+		{"standard workloads", standardWorkloads, nil},
+		{"failing workloads", []workload{
+			{"hello", http.MethodGet, "/", ""},
+			{"bad json", http.MethodPost, "/process/normal", `{"name":`},
+			{"unknown func", http.MethodPost, "/process/cpu-intensive?func=nope", ""},
+			{"missing route", http.MethodGet, "/nope", ""},
+		}, []int{http.StatusOK, http.StatusBadRequest, http.StatusBadRequest, http.StatusNotFound}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, &standardWorkloads, tt.workloads)
+			runBaseline(newReplayRouter())
+
+			if len(config.baseline) != len(tt.workloads) {
+				t.Fatalf("%d results, want %d", len(config.baseline), len(tt.workloads))
+			}
+			for i, result := range config.baseline {
+				want := http.StatusOK
+				if tt.status != nil {
+					want = tt.status[i]
+				}
+				if result.Name != tt.workloads[i].Name || result.Status != want {
+					t.Errorf("result %d = %s %d, want %s %d", i, result.Name, result.Status, tt.workloads[i].Name, want)
+				}
+				if result.ElapsedSeconds <= 0 {
+					t.Errorf("%s elapsed_seconds = %v", result.Name, result.ElapsedSeconds)
+				}
+			}
+		})
+	}
+
+	// /config reports the last run
+	r := gin.New()
+	r.GET("/config", handleConfig)
+	resp := decodeJSON(t, doRequest(r, http.MethodGet, "/config", ""))
+	if baseline, _ := resp["baseline"].([]interface{}); len(baseline) != 4 {
+		t.Errorf("/config baseline = %v, want 4 results", resp["baseline"])
+	}
+}
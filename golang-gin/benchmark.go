@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Longest /benchmark run accepted, summed over every workload and level
+const maxBenchmarkSeconds = 300
+
+// Defaults for /benchmark when the request leaves a field out
+var (
+	defaultBenchmarkWorkloads   = []string{"hello", "normal", "cpu-intensive", "reverse"}
+	defaultBenchmarkConcurrency = []int{1, 4, 16}
+)
+
+// BenchmarkRequest selects standard workloads by name and the concurrency
+// levels to drive each of them at.
+type BenchmarkRequest struct {
+	Workloads   []string `json:"workloads"`
+	Concurrency []int    `json:"concurrency"`
+	// Seconds per run (default 1); every run together must fit in the
+	// -request-timeout
+	DurationS float64 `json:"duration_s"`
+	// "json" (default) or "csv"; ?format= also works
+	Format string `json:"format"`
+}
+
+// benchmarkRun is one workload at one concurrency level.
+type benchmarkRun struct {
+	Workload    string `json:"workload"`
+	Concurrency int    `json:"concurrency"`
+	loadReport
+}
+
+// handleBenchmark runs each selected workload at each concurrency level in
+// sequence, reusing the /selfload machinery, and returns every run in one
+// report.
+func handleBenchmark(handler http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BenchmarkRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if req.Format == "" {
+			req.Format = c.DefaultQuery("format", "json")
+		}
+		if req.Format != "json" && req.Format != "csv" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+			return
+		}
+
+		if len(req.Workloads) == 0 {
+			req.Workloads = defaultBenchmarkWorkloads
+		}
+		workloads := make([]workload, 0, len(req.Workloads))
+		for _, name := range req.Workloads {
+			w, ok := standardWorkload(name)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown workload: " + name})
+				return
+			}
+			workloads = append(workloads, w)
+		}
+
+		if len(req.Concurrency) == 0 {
+			req.Concurrency = defaultBenchmarkConcurrency
+		}
+		for _, level := range req.Concurrency {
+			if level < 1 || level > maxSelfLoadConcurrency {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("concurrency must be between 1 and %d", maxSelfLoadConcurrency)})
+				return
+			}
+		}
+		if req.DurationS == 0 {
+			req.DurationS = 1
+		}
+		if err := checkLoadDuration(req.DurationS); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		total := req.DurationS * float64(len(workloads)*len(req.Concurrency))
+		if total > maxBenchmarkSeconds {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("benchmark would run for %.0fs, more than the maximum of %ds", total, maxBenchmarkSeconds)})
+			return
+		}
+		if timeout := config.RequestTimeout; timeout > 0 && total > timeout.Seconds() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("benchmark would run for %gs, more than the request timeout of %g seconds", total, timeout.Seconds())})
+			return
+		}
+
+		duration := time.Duration(req.DurationS * float64(time.Second))
+		start := time.Now()
+		runs := make([]benchmarkRun, 0, len(workloads)*len(req.Concurrency))
+		for _, w := range workloads {
+			for _, level := range req.Concurrency {
+				report := runLoad(c.Request.Context(), handler, w, level, duration)
+				logf("info", "benchmark: %s at concurrency %d: %.1f req/s", w.Name, level, report.RequestsPerSecond)
+				runs = append(runs, benchmarkRun{Workload: w.Name, Concurrency: level, loadReport: report})
+			}
+		}
+		if timedOut(c) {
+			return
+		}
+
+		if req.Format == "csv" {
+			c.Data(http.StatusOK, "text/csv; charset=utf-8", benchmarkCSV(runs))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"runs":          runs,
+			"total_seconds": time.Since(start).Seconds(),
+			"duration_s":    req.DurationS,
+			"service":       "Go Gin",
+		})
+	}
+}
+
+// standardWorkload looks up one of the standard workloads by name.
+func standardWorkload(name string) (workload, bool) {
+	for _, w := range standardWorkloads {
+		if w.Name == name {
+			return w, true
+		}
+	}
+	return workload{}, false
+}
+
+// benchmarkCSV renders one row per run with the latency percentiles
+// flattened into columns.
+func benchmarkCSV(runs []benchmarkRun) []byte {
+	var out strings.Builder
+	writer := csv.NewWriter(&out)
+	percentiles := []string{"min", "mean", "p50", "p90", "p95", "p99", "max"}
+
+	header := []string{"workload", "concurrency", "requests", "errors", "duration_seconds", "requests_per_second"}
+	for _, p := range percentiles {
+		header = append(header, p+"_ms")
+	}
+	writer.Write(header)
+
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', 3, 64) }
+	for _, run := range runs {
+		row := []string{
+			run.Workload,
+			strconv.Itoa(run.Concurrency),
+			strconv.Itoa(run.Requests),
+			strconv.Itoa(run.Errors),
+			formatFloat(run.DurationSeconds),
+			formatFloat(run.RequestsPerSecond),
+		}
+		for _, p := range percentiles {
+			value, _ := run.LatencyMs[p].(float64)
+			row = append(row, formatFloat(value))
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return []byte(out.String())
+}
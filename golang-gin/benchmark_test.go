@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBenchmark(t *testing.T) {
+	setConfig(t, &config.RequestTimeout, 2*time.Second)
+	r := gin.New()
+	r.POST("/benchmark", handleBenchmark(newReplayRouter()))
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		runs   int
+	}{
+		{"two workloads at two levels", "/benchmark", `{"workloads":["hello","reverse"],"concurrency":[1,2],"duration_s":0.1}`, http.StatusOK, 4},
+		{"csv", "/benchmark?format=csv", `{"workloads":["hello"],"concurrency":[1,4],"duration_s":0.1}`, http.StatusOK, 2},
+		{"unknown workload", "/benchmark", `{"workloads":["nope"],"duration_s":0.1}`, http.StatusBadRequest, 0},
+		{"bad concurrency", "/benchmark", `{"workloads":["hello"],"concurrency":[0],"duration_s":0.1}`, http.StatusBadRequest, 0},
+		{"negative duration", "/benchmark", `{"workloads":["hello"],"duration_s":-1}`, http.StatusBadRequest, 0},
+		// 4 runs of 0.6s is past the 2s timeout
+		{"total past the request timeout", "/benchmark", `{"workloads":["hello","reverse"],"concurrency":[1,2],"duration_s":0.6}`, http.StatusBadRequest, 0},
+		{"bad format", "/benchmark?format=xml", `{"workloads":["hello"],"duration_s":0.1}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, tt.path, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			if strings.HasSuffix(tt.path, "format=csv") {
+				rows, err := csv.NewReader(w.Body).ReadAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(rows) != tt.runs+1 || rows[0][0] != "workload" || rows[0][len(rows[0])-1] != "max_ms" {
+					t.Errorf("csv = %v, want a header and %d runs", rows, tt.runs)
+				}
+				return
+			}
+
+			var resp struct {
+				Runs []benchmarkRun `json:"runs"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Runs) != tt.runs {
+				t.Fatalf("%d runs, want %d", len(resp.Runs), tt.runs)
+			}
+			for _, run := range resp.Runs {
+				checkLoadReport(t, run.loadReport)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Responses shorter than this are sent uncompressed; gzip's framing would
+// make them bigger and cost more than it saves.
+const gzipMinBytes = 1024
+
+// Streaming routes are never compressed: gzip would shrink /stream's
+// repetitive payload to almost nothing and hold back small events.
+var uncompressedRoutes = map[string]bool{
+	"/stream": true,
+	"/sse":    true,
+	"/ws":     true,
+}
+
+// compressResponses gzips response bodies of at least gzipMinBytes for
+// clients that send Accept-Encoding: gzip. The start of the body is held
+// back until the size is known, so small responses go out as they were.
+func compressResponses() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) || uncompressedRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		w := &gzipWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// gzipWriter buffers the body until it reaches gzipMinBytes, then switches
+// to streaming it through a gzip.Writer.
+type gzipWriter struct {
+	gin.ResponseWriter
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	decided bool
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() >= gzipMinBytes {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow is deferred along with the body, since compressing
+// changes the headers.
+func (w *gzipWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Flush sends what has been written so far. A streaming handler is not
+// worth holding back, so anything still undecided goes out uncompressed.
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		// More may follow, so the length of what is buffered is not the
+		// length of the body
+		w.decided = true
+		w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipWriter) startGzip() error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || w.Status() == http.StatusNoContent {
+		_, err := w.ResponseWriter.Write(w.buf.Bytes())
+		return err
+	}
+
+	header.Set("Content-Encoding", "gzip")
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf.Bytes())
+	return err
+}
+
+func (w *gzipWriter) passThrough() {
+	w.decided = true
+	if w.buf.Len() == 0 {
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	w.ResponseWriter.Write(w.buf.Bytes())
+}
+
+// finish writes out a body that never reached the threshold, or closes
+// the gzip stream.
+func (w *gzipWriter) finish() {
+	if !w.decided {
+		w.passThrough()
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCompressResponses(t *testing.T) {
+	large := gin.H{"words": strings.Split(strings.Repeat("lorem ipsum dolor sit amet ", 100), " ")}
+	small := gin.H{"message": "Hello, World!"}
+
+	r := gin.New()
+	r.Use(compressResponses())
+	r.GET("/large", func(c *gin.Context) { c.JSON(http.StatusOK, large) })
+	r.GET("/small", func(c *gin.Context) { c.JSON(http.StatusOK, small) })
+	r.GET("/stream", func(c *gin.Context) { c.JSON(http.StatusOK, large) })
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		gzipped        bool
+		want           gin.H
+	}{
+		{"large", "/large", "gzip", true, large},
+		{"large with q-values", "/large", "deflate;q=1.0, gzip;q=0.8", true, large},
+		{"large, gzip refused", "/large", "gzip;q=0", false, large},
+		{"large, no Accept-Encoding", "/large", "", false, large},
+		{"below the threshold", "/small", "gzip", false, small},
+		{"streaming route", "/stream", "gzip", false, large},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var body io.Reader = w.Body
+			if encoding := w.Header().Get("Content-Encoding"); (encoding == "gzip") != tt.gzipped {
+				t.Fatalf("Content-Encoding = %q, want gzip %v", encoding, tt.gzipped)
+			}
+			if tt.gzipped {
+				gz, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = gz
+			}
+
+			var got gin.H
+			if err := json.NewDecoder(body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			// Round-trip the expectation so both sides have JSON types
+			want, _ := json.Marshal(tt.want)
+			var wantDecoded gin.H
+			json.Unmarshal(want, &wantDecoded)
+			if !reflect.DeepEqual(got, wantDecoded) {
+				t.Error("body does not match the uncompressed JSON")
+			}
+		})
+	}
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPort = 6002
+
+// Log levels, in increasing order of severity
+var logLevels = map[string]int{
+	"debug": 0,
+	"info":  1,
+	"warn":  2,
+	"error": 3,
+}
+
+// Settings holds the configuration that may change while the server is
+// running. It is re-read from the config file on SIGHUP.
+type Settings struct {
+	LogLevel string `json:"log_level"`
+
+	// Maintenance mode rejects /process requests and marks the server not ready
+	Maintenance bool `json:"maintenance"`
+
+	// Requests per second accepted across all clients before answering
+	// 429 (0 disables), and how many may arrive at once above that rate
+	// (0 means the same as rate_limit)
+	RateLimit int `json:"rate_limit"`
+	RateBurst int `json:"rate_burst"`
+
+	// Delay added before every /process request, and the fraction of them
+	// (0 to 1) answered with an injected 500, for testing how clients
+	// cope with a slow or failing server
+	InjectLatencyMS int     `json:"inject_latency_ms"`
+	ErrorRate       float64 `json:"error_rate"`
+}
+
+// Config holds the startup configuration plus the current mutable settings.
+type Config struct {
+	Port       int
+	ConfigFile string
+	AuthToken  string
+	Baseline   bool
+
+	// OTLP/HTTP collector URL; tracing is disabled when empty
+	OTLPEndpoint string
+
+	// Deepest JSON nesting accepted in request bodies (0 disables the check)
+	MaxJSONDepth int
+
+	// /health reports 503 when the working directory has less free space
+	MinFreeDiskMB int
+
+	// Honour X-Disable-GC; see disableGC for the memory risk
+	AllowGCDisable bool
+
+	// /process requests get a 503 above this many goroutines (0 disables)
+	MaxGoroutines int
+
+	// Largest fibonacci n accepted by /process/cpu-intensive and
+	// /process/parallel; past F(92) each step is a big-integer addition
+	MaxN int
+
+	// Largest prime_limit accepted by /process/cpu-intensive; the sieve
+	// allocates a byte per number
+	MaxPrimeLimit int
+
+	// Largest size_mb accepted by /process/memory
+	MaxMemoryMB int
+
+	// Gzip large responses for clients that accept it (off by default, so
+	// benchmark results don't change with the client's Accept-Encoding)
+	Gzip bool
+
+	// Deadline for each /process request (0 disables)
+	RequestTimeout time.Duration
+
+	// Largest delay_ms accepted by /process/io
+	MaxDelayMS int
+
+	// Largest record count accepted by /process/json
+	MaxJSONRecords int
+
+	// Most records accepted by /process/normal/batch
+	MaxBatchSize int
+
+	// Serve the runtime profiler under /debug/pprof
+	Pprof bool
+
+	// Largest size accepted by /process/matrix; the multiply is O(size^3)
+	MaxMatrixSize int
+
+	// Largest count accepted by /process/sort
+	MaxSortCount int
+
+	// Request bodies larger than this get a 413 (0 disables)
+	MaxBodyMB int
+
+	// Largest size_mb accepted by /stream
+	MaxStreamMB int
+
+	// GOMAXPROCS to run with; values below 1 leave the runtime's default
+	MaxProcs int
+
+	// How many cpu-intensive and matrix requests may run at once (0
+	// disables the pool; the default is GOMAXPROCS), and whether the
+	// rest "queue" or get a 429 ("reject")
+	CPUWorkers    int
+	CPUPoolPolicy string
+
+	// Origins allowed to call the server from a browser, comma-separated
+	// ("*" for any; empty disables CORS), and what preflights may ask for
+	CORSOrigins string
+	CORSMethods string
+	CORSHeaders string
+
+	// Default seed for workloads that generate pseudo-random input; a
+	// request's own seed overrides it
+	Seed int64
+
+	// "text" or "json" for the access log and server log lines
+	LogFormat string
+
+	// How long to keep serving, with /health/ready failing, after a
+	// shutdown signal and before the listener closes
+	ShutdownDelay time.Duration
+
+	// Settings given on the command line; the config file is layered on
+	// top of these on every load.
+	base Settings
+
+	mu          sync.RWMutex
+	settings    Settings
+	reloadCount int
+	lastReload  time.Time
+
+	// Startup self-benchmark results, when -baseline is set
+	baseline []baselineResult
+}
+
+var config = &Config{Port: defaultPort}
+
+// immutableSettings are config file keys that only take effect at startup.
+var immutableSettings = []string{"port", "auth_token", "otlp_endpoint", "max_json_depth", "min_free_disk_mb", "allow_gc_disable", "max_goroutines", "max_n", "max_prime_limit", "max_memory_mb", "gzip", "request_timeout", "max_delay_ms", "max_json_records", "max_batch_size", "pprof", "max_matrix_size", "shutdown_delay", "log_format", "max_sort_count", "max_body_mb", "max_stream_mb", "maxprocs", "cpu_workers", "cpu_pool_policy", "seed", "cors_origins", "cors_methods", "cors_headers"}
+
+// loadConfig parses command-line flags (with environment fallbacks) and
+// applies the config file, if one was given.
+func loadConfig() error {
+	flag.IntVar(&config.Port, "port", defaultPort, "port to listen on (overrides PORT)")
+	flag.StringVar(&config.ConfigFile, "config", os.Getenv("CONFIG_FILE"), "path to a JSON file with reloadable settings")
+	flag.StringVar(&config.AuthToken, "auth-token", os.Getenv("AUTH_TOKEN"), "bearer token required on /process endpoints (disabled when empty)")
+	flag.BoolVar(&config.Baseline, "baseline", os.Getenv("BASELINE") != "", "run every workload once at startup and log the timings")
+	flag.StringVar(&config.OTLPEndpoint, "otlp-endpoint", os.Getenv("OTLP_ENDPOINT"), "OTLP/HTTP collector URL to export request traces to")
+	flag.IntVar(&config.MaxJSONDepth, "max-json-depth", envInt("MAX_JSON_DEPTH", 32), "maximum JSON nesting depth accepted in request bodies (0 disables)")
+	flag.IntVar(&config.MinFreeDiskMB, "min-free-disk-mb", envInt("MIN_FREE_DISK_MB", 100), "free disk space in MB below which /health reports 503")
+	flag.BoolVar(&config.AllowGCDisable, "allow-gc-disable", os.Getenv("ALLOW_GC_DISABLE") != "", "let requests disable the GC with X-Disable-GC (heap grows unbounded while they run)")
+	flag.IntVar(&config.MaxGoroutines, "max-goroutines", envInt("MAX_GOROUTINES", 0), "reject /process requests with 503 while more goroutines than this are running (0 disables)")
+	flag.IntVar(&config.MaxN, "max-n", envInt("MAX_N", 100000), "largest fibonacci n accepted by /process/cpu-intensive")
+	flag.IntVar(&config.MaxPrimeLimit, "max-prime-limit", envInt("MAX_PRIME_LIMIT", 10000000), "largest prime_limit accepted by /process/cpu-intensive")
+	flag.IntVar(&config.MaxMemoryMB, "max-memory-mb", envInt("MAX_MEMORY_MB", 256), "largest size_mb accepted by /process/memory")
+	flag.BoolVar(&config.Gzip, "gzip", os.Getenv("ENABLE_GZIP") != "", "gzip responses of 1KB or more when the client accepts it")
+	flag.DurationVar(&config.RequestTimeout, "request-timeout", envDuration("REQUEST_TIMEOUT", 30*time.Second), "deadline for /process requests, after which they get a 503 (0 disables)")
+	flag.IntVar(&config.MaxDelayMS, "max-delay-ms", envInt("MAX_DELAY_MS", 10000), "largest delay_ms accepted by /process/io")
+	flag.IntVar(&config.MaxJSONRecords, "max-json-records", envInt("MAX_JSON_RECORDS", 100000), "largest count accepted by /process/json")
+	flag.IntVar(&config.MaxBatchSize, "max-batch-size", envInt("MAX_BATCH_SIZE", 1000), "most records accepted by /process/normal/batch")
+	flag.BoolVar(&config.Pprof, "pprof", os.Getenv("PPROF") != "", "serve net/http/pprof profiles under /debug/pprof")
+	flag.IntVar(&config.MaxMatrixSize, "max-matrix-size", envInt("MAX_MATRIX_SIZE", 1000), "largest size accepted by /process/matrix")
+	flag.DurationVar(&config.ShutdownDelay, "shutdown-delay", envDuration("SHUTDOWN_DELAY", 0), "keep serving this long after SIGTERM with /health/ready at 503, so traffic can drain")
+	flag.IntVar(&config.MaxSortCount, "max-sort-count", envInt("MAX_SORT_COUNT", 10000000), "largest count accepted by /process/sort")
+	flag.IntVar(&config.MaxBodyMB, "max-body-mb", envInt("MAX_BODY_MB", 4), "largest request body accepted, in MB (0 disables)")
+	flag.IntVar(&config.MaxStreamMB, "max-stream-mb", envInt("MAX_STREAM_MB", 1024), "largest size_mb accepted by /stream")
+	flag.IntVar(&config.MaxProcs, "maxprocs", envInt("MAXPROCS", 0), "set GOMAXPROCS at startup (ignored when below 1)")
+	flag.IntVar(&config.CPUWorkers, "cpu-workers", envInt("CPU_WORKERS", -1), "how many cpu-intensive and matrix requests may run at once (0 disables the limit; defaults to GOMAXPROCS)")
+	flag.StringVar(&config.CPUPoolPolicy, "cpu-pool-policy", envOr("CPU_POOL_POLICY", "queue"), "what to do with requests while every CPU worker is busy: queue or reject")
+	flag.Int64Var(&config.Seed, "seed", envInt64("SEED", time.Now().UnixNano()), "default seed for generated workload input (time-based when unset)")
+	flag.StringVar(&config.CORSOrigins, "cors-origins", os.Getenv("CORS_ORIGINS"), "comma-separated origins allowed to make cross-origin requests, or * for any (disabled when empty)")
+	flag.StringVar(&config.CORSMethods, "cors-methods", envOr("CORS_METHODS", "GET, POST, OPTIONS"), "methods allowed in CORS preflight responses")
+	flag.StringVar(&config.CORSHeaders, "cors-headers", envOr("CORS_HEADERS", "Content-Type, Authorization, Accept, X-Request-ID, X-CPU-Time, X-Disable-GC, X-Include-Serialization, X-Body-SHA256"), "request headers allowed in CORS preflight responses")
+	flag.StringVar(&config.LogFormat, "log-format", envOr("LOG_FORMAT", "text"), "log output format: text or json")
+	flag.StringVar(&config.base.LogLevel, "log-level", envOr("LOG_LEVEL", "info"), "log level: debug, info, warn or error")
+	flag.IntVar(&config.base.RateLimit, "rate-limit", envInt("RATE_LIMIT", 0), "requests per second accepted across all clients before answering 429 (0 disables)")
+	flag.IntVar(&config.base.RateBurst, "rate-burst", envInt("RATE_BURST", 0), "requests allowed at once above -rate-limit (defaults to -rate-limit)")
+	flag.IntVar(&config.base.InjectLatencyMS, "inject-latency-ms", envInt("INJECT_LATENCY_MS", 0), "delay added before every /process request, in milliseconds")
+	flag.Float64Var(&config.base.ErrorRate, "error-rate", envFloat("ERROR_RATE", 0), "fraction of /process requests (0 to 1) answered with an injected 500")
+	flag.BoolVar(&config.base.Maintenance, "maintenance", os.Getenv("MAINTENANCE") != "", "start in maintenance mode (process endpoints return 503)")
+	flag.Parse()
+
+	if err := resolvePort(); err != nil {
+		return err
+	}
+	switch config.LogFormat {
+	case "text":
+	case "json":
+		log.SetFlags(0)
+	default:
+		return fmt.Errorf("invalid log format %q: must be text or json", config.LogFormat)
+	}
+
+	if config.MaxProcs > 0 {
+		runtime.GOMAXPROCS(config.MaxProcs)
+	}
+	if config.CPUWorkers < 0 {
+		config.CPUWorkers = runtime.GOMAXPROCS(0)
+	}
+	if config.CPUPoolPolicy != "queue" && config.CPUPoolPolicy != "reject" {
+		return fmt.Errorf("invalid cpu pool policy %q: must be queue or reject", config.CPUPoolPolicy)
+	}
+
+	settings, err := config.readSettings()
+	if err != nil {
+		return err
+	}
+	config.mu.Lock()
+	config.settings = settings
+	config.mu.Unlock()
+	return nil
+}
+
+// resolvePort applies the PORT environment variable unless -port was given,
+// and checks the result is a usable TCP port.
+func resolvePort() error {
+	portFlagSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "port" {
+			portFlagSet = true
+		}
+	})
+	if raw, ok := os.LookupEnv("PORT"); ok && !portFlagSet {
+		port, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid PORT %q: must be an integer", raw)
+		}
+		config.Port = port
+	}
+	if config.Port < 1 || config.Port > 65535 {
+		return fmt.Errorf("invalid port %d: must be between 1 and 65535", config.Port)
+	}
+	return nil
+}
+
+// envOr returns the value of the environment variable key, or def when unset.
+func envOr(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return def
+}
+
+// envInt returns the environment variable key parsed as an integer, or def
+// when it is unset or invalid.
+func envInt(key string, def int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return def
+}
+
+// envInt64 returns the environment variable key parsed as an int64, or def
+// when it is unset or invalid.
+func envInt64(key string, def int64) int64 {
+	if value, err := strconv.ParseInt(os.Getenv(key), 10, 64); err == nil {
+		return value
+	}
+	return def
+}
+
+// envFloat returns the environment variable key parsed as a float64, or def
+// when it is unset or invalid.
+func envFloat(key string, def float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return def
+}
+
+// envDuration returns the environment variable key parsed as a duration
+// (e.g. "30s"), or def when it is unset or invalid.
+func envDuration(key string, def time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return def
+}
+
+// readSettings merges the config file over the command-line settings and
+// validates the result.
+func (cfg *Config) readSettings() (Settings, error) {
+	settings := cfg.base
+	if cfg.ConfigFile != "" {
+		data, err := os.ReadFile(cfg.ConfigFile)
+		if err != nil {
+			return settings, fmt.Errorf("reading config file: %w", err)
+		}
+
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return settings, fmt.Errorf("parsing config file: %w", err)
+		}
+		for _, key := range immutableSettings {
+			if _, ok := keys[key]; ok {
+				logf("warn", "config: ignoring %q, it can only be set at startup", key)
+			}
+		}
+
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return settings, fmt.Errorf("parsing config file: %w", err)
+		}
+	}
+
+	if _, ok := logLevels[settings.LogLevel]; !ok {
+		return settings, fmt.Errorf("invalid log level %q", settings.LogLevel)
+	}
+	if settings.RateLimit < 0 || settings.RateBurst < 0 {
+		return settings, fmt.Errorf("invalid rate limit: rate_limit and rate_burst must not be negative")
+	}
+	if settings.InjectLatencyMS < 0 || settings.InjectLatencyMS > maxInjectedLatencyMS {
+		return settings, fmt.Errorf("invalid inject_latency_ms: must be between 0 and %d", maxInjectedLatencyMS)
+	}
+	if !(settings.ErrorRate >= 0 && settings.ErrorRate <= 1) {
+		return settings, fmt.Errorf("invalid error_rate: must be between 0 and 1")
+	}
+	return settings, nil
+}
+
+// Settings returns a snapshot of the current mutable settings.
+func (cfg *Config) Settings() Settings {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	return cfg.settings
+}
+
+// reload re-reads the mutable settings. On error the previous settings
+// stay in effect.
+func (cfg *Config) reload() error {
+	settings, err := cfg.readSettings()
+	if err != nil {
+		return err
+	}
+
+	cfg.mu.Lock()
+	cfg.settings = settings
+	cfg.reloadCount++
+	cfg.lastReload = time.Now().UTC()
+	cfg.mu.Unlock()
+	return nil
+}
+
+// watchReload reloads the configuration every time the process receives SIGHUP.
+func watchReload() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		if err := config.reload(); err != nil {
+			logf("error", "config: reload failed, keeping previous settings: %v", err)
+			continue
+		}
+		logf("info", "config: reloaded settings")
+	}
+}
+
+// logf writes a log line if level is at or above the configured log level.
+func logf(level, format string, args ...interface{}) {
+	if logLevels[level] < logLevels[config.Settings().LogLevel] {
+		return
+	}
+	if config.LogFormat == "json" {
+		line, _ := json.Marshal(map[string]string{
+			"time":  time.Now().UTC().Format(time.RFC3339Nano),
+			"level": level,
+			"msg":   fmt.Sprintf(format, args...),
+		})
+		log.Print(string(line))
+		return
+	}
+	log.Printf("["+level+"] "+format, args...)
+}
+
+// accessLogEntry is one request in the JSON access log.
+type accessLogEntry struct {
+	Time      string  `json:"time"`
+	RequestID string  `json:"request_id"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Status    int     `json:"status"`
+	LatencyNs int64   `json:"latency_ns"`
+	LatencyMs float64 `json:"latency_ms"`
+	ClientIP  string  `json:"client_ip"`
+}
+
+// accessLog wraps Gin's request logger so it follows the configured log
+// level, or writes one JSON object per request with -log-format json.
+func accessLog() gin.HandlerFunc {
+	logger := gin.Logger()
+	return func(c *gin.Context) {
+		if logLevels[config.Settings().LogLevel] > logLevels["info"] {
+			c.Next()
+			return
+		}
+		if config.LogFormat != "json" {
+			logger(c)
+			return
+		}
+
+		start := time.Now()
+		path := c.Request.URL.Path
+		if raw := c.Request.URL.RawQuery; raw != "" {
+			path += "?" + raw
+		}
+		c.Next()
+		latency := time.Since(start)
+
+		line, _ := json.Marshal(accessLogEntry{
+			Time:      start.UTC().Format(time.RFC3339Nano),
+			RequestID: c.GetString(requestIDKey),
+			Method:    c.Request.Method,
+			Path:      path,
+			Status:    c.Writer.Status(),
+			LatencyNs: latency.Nanoseconds(),
+			LatencyMs: float64(latency) / float64(time.Millisecond),
+			ClientIP:  c.ClientIP(),
+		})
+		fmt.Fprintln(gin.DefaultWriter, string(line))
+	}
+}
+
+func handleConfig(c *gin.Context) {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+
+	var lastReload interface{}
+	if !config.lastReload.IsZero() {
+		lastReload = config.lastReload.Format(time.RFC3339)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"port":             config.Port,
+		"config_file":      config.ConfigFile,
+		"auth_enabled":     config.AuthToken != "",
+		"otlp_endpoint":    config.OTLPEndpoint,
+		"max_json_depth":   config.MaxJSONDepth,
+		"min_free_disk_mb": config.MinFreeDiskMB,
+		"allow_gc_disable": config.AllowGCDisable,
+		"max_goroutines":   config.MaxGoroutines,
+		"max_n":            config.MaxN,
+		"max_prime_limit":  config.MaxPrimeLimit,
+		"max_memory_mb":    config.MaxMemoryMB,
+		"gzip":             config.Gzip,
+		"request_timeout":  config.RequestTimeout.String(),
+		"max_delay_ms":     config.MaxDelayMS,
+		"max_json_records": config.MaxJSONRecords,
+		"max_batch_size":   config.MaxBatchSize,
+		"pprof":            config.Pprof,
+		"max_matrix_size":  config.MaxMatrixSize,
+		"shutdown_delay":   config.ShutdownDelay.String(),
+		"log_format":       config.LogFormat,
+		"max_sort_count":   config.MaxSortCount,
+		"max_body_mb":      config.MaxBodyMB,
+		"max_stream_mb":    config.MaxStreamMB,
+		"maxprocs":         config.MaxProcs,
+		"cpu_workers":      config.CPUWorkers,
+		"cpu_pool_policy":  config.CPUPoolPolicy,
+		"seed":             config.Seed,
+		"cors_origins":     config.CORSOrigins,
+		"cors_methods":     config.CORSMethods,
+		"cors_headers":     config.CORSHeaders,
+		"settings":         config.settings,
+		"reload_count":     config.reloadCount,
+		"last_reload":      lastReload,
+		"baseline":         config.baseline,
+	})
+}
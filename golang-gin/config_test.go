@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResolvePort(t *testing.T) {
+	tests := []struct {
+		env     string
+		port    int
+		wantErr bool
+	}{
+		{"9090", 9090, false},
+		{"1", 1, false},
+		{"65535", 65535, false},
+		{"0", 0, true},
+		{"65536", 0, true},
+		{"http", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			setConfig(t, &config.Port, defaultPort)
+			t.Setenv("PORT", tt.env)
+			err := resolvePort()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolvePort() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && config.Port != tt.port {
+				t.Errorf("port = %d, want %d", config.Port, tt.port)
+			}
+		})
+	}
+}
+
+func TestServeBindsToPortFromEnv(t *testing.T) {
+	port := freePort(t)
+	setConfig(t, &config.Port, defaultPort)
+	t.Setenv("PORT", strconv.Itoa(port))
+	if err := resolvePort(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { setNotReady("") })
+
+	r := gin.New()
+	r.GET("/", handleHelloWorld)
+	server := startServer(t, r)
+	defer stopServer(t, server)
+
+	resp, err := testClient.Get(fmt.Sprintf("http://127.0.0.1:%d/", port))
+	if err != nil {
+		t.Fatalf("nothing listening on port %d: %v", port, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+// TestMaxProcsFlag runs the test binary again with -maxprocs, since the flag
+// is applied once by loadConfig in TestMain.
+func TestMaxProcsFlag(t *testing.T) {
+	if want := os.Getenv("MAXPROCS_TEST_WANT"); want != "" {
+		r := gin.New()
+		r.GET("/info", handleInfo)
+		w := doRequest(r, http.MethodGet, "/info", "")
+		if got := fmt.Sprint(decodeJSON(t, w)["gomaxprocs"]); got != want {
+			t.Fatalf("/info gomaxprocs = %s, want %s", got, want)
+		}
+		return
+	}
+
+	tests := []struct {
+		maxprocs string
+		want     int
+	}{
+		{"1", 1},
+		{"2", 2},
+		{"0", runtime.GOMAXPROCS(0)},
+		{"-3", runtime.GOMAXPROCS(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.maxprocs, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestMaxProcsFlag$", "-maxprocs="+tt.maxprocs)
+			cmd.Env = append(os.Environ(), "MAXPROCS_TEST_WANT="+strconv.Itoa(tt.want), "MAXPROCS=")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("-maxprocs=%s: %v\n%s", tt.maxprocs, err, out)
+			}
+		})
+	}
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	setConfig(t, &config.LogFormat, "json")
+	var out bytes.Buffer
+	setConfig(t, &gin.DefaultWriter, io.Writer(&out))
+
+	r := gin.New()
+	r.Use(requestID(), accessLog())
+	r.GET("/", handleHelloWorld)
+
+	req := httptest.NewRequest(http.MethodGet, "/?x=1", nil)
+	req.Header.Set("X-Request-ID", "bench-run-42")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry accessLogEntry
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("decoding log line %q: %v", out.String(), err)
+	}
+	if entry.RequestID != "bench-run-42" || entry.Method != http.MethodGet || entry.Path != "/?x=1" || entry.Status != http.StatusOK {
+		t.Errorf("log entry = %+v", entry)
+	}
+	if entry.LatencyNs <= 0 {
+		t.Errorf("latency_ns = %d, want a positive duration", entry.LatencyNs)
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	setConfig(t, &config.ConfigFile, path)
+	config.mu.Lock()
+	oldSettings, oldCount, oldLast := config.settings, config.reloadCount, config.lastReload
+	config.mu.Unlock()
+	t.Cleanup(func() {
+		config.mu.Lock()
+		config.settings, config.reloadCount, config.lastReload = oldSettings, oldCount, oldLast
+		config.mu.Unlock()
+	})
+
+	steps := []struct {
+		name    string
+		file    string
+		wantErr bool
+		want    Settings
+	}{
+		{
+			"first load",
+			`{"log_level":"warn","rate_limit":50,"inject_latency_ms":20,"error_rate":0.25}`,
+			false,
+			Settings{LogLevel: "warn", RateLimit: 50, InjectLatencyMS: 20, ErrorRate: 0.25},
+		},
+		{
+			"rewritten",
+			`{"log_level":"debug","rate_limit":10,"rate_burst":20,"maintenance":true,"error_rate":1}`,
+			false,
+			Settings{LogLevel: "debug", RateLimit: 10, RateBurst: 20, Maintenance: true, ErrorRate: 1},
+		},
+		{
+			"immutable keys are ignored",
+			`{"log_level":"info","port":1,"max_n":1}`,
+			false,
+			Settings{LogLevel: "info"},
+		},
+		{"error rate above 1", `{"log_level":"info","error_rate":1.5}`, true, Settings{LogLevel: "info"}},
+		{"negative latency", `{"log_level":"info","inject_latency_ms":-1}`, true, Settings{LogLevel: "info"}},
+		{"latency over the cap", fmt.Sprintf(`{"log_level":"info","inject_latency_ms":%d}`, maxInjectedLatencyMS+1), true, Settings{LogLevel: "info"}},
+		{"unknown log level", `{"log_level":"loud"}`, true, Settings{LogLevel: "info"}},
+		{"malformed", `{"log_level":`, true, Settings{LogLevel: "info"}},
+	}
+	port, maxN := config.Port, config.MaxN
+	for _, step := range steps {
+		if err := os.WriteFile(path, []byte(step.file), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		before := config.reloadCount
+		err := config.reload()
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: error = %v, want error %v", step.name, err, step.wantErr)
+		}
+		// A failed reload keeps the previous settings
+		if got := config.Settings(); got != step.want {
+			t.Errorf("%s: settings = %+v, want %+v", step.name, got, step.want)
+		}
+		wantCount := before + 1
+		if step.wantErr {
+			wantCount = before
+		}
+		if config.reloadCount != wantCount {
+			t.Errorf("%s: reload_count = %d, want %d", step.name, config.reloadCount, wantCount)
+		}
+	}
+	if config.Port != port || config.MaxN != maxN {
+		t.Errorf("port = %d, max_n = %d after reloads, want %d and %d", config.Port, config.MaxN, port, maxN)
+	}
+}
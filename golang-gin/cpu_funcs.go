@@ -0,0 +1,1051 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"math/cmplx"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Upper bounds for the alternative CPU-intensive workloads
+const (
+	maxFFTSize        = 1 << 20
+	maxCatalanN       = 20000
+	maxPerfectLimit   = 1000000
+	maxJosephusN      = 100000000
+	maxJosephusK      = 1000
+	maxPascalRows     = 1000
+	maxHanoiDisks     = 10000
+	maxTotientLimit   = 10000000
+	maxFactorialN     = 100000
+	maxContFracDigits = 10000
+	maxContFracTerms  = 10000
+	maxHullPoints     = 2000000
+	maxLucasN         = 1000000
+	maxMarkovOrder    = 10
+	maxMarkovLength   = 1000000
+	maxMarkovCorpus   = 100000
+	// The naive method does n big-integer additions instead of log n products
+	maxLucasNaiveN = 100000
+	// The baby-step table holds sqrt(mod) entries
+	maxDlogModulus = 1000000000000
+	// The move sequence has 2^N - 1 entries, so it is capped much lower
+	maxHanoiSequenceDisks = 16
+
+	// Backtracking steps allowed before a sudoku is abandoned
+	maxSudokuSteps = 10000000
+)
+
+// Iterations between deadline checks in long CPU loops. A power of two,
+// so most iterations skip the check with a mask.
+const ctxCheckInterval = 1 << 12
+
+// canceled reports whether ctx has ended, looking only on every
+// ctxCheckInterval-th iteration i so the check costs next to nothing.
+func canceled(ctx context.Context, i int) bool {
+	return i&(ctxCheckInterval-1) == 0 && ctx.Err() != nil
+}
+
+// Runes of generated text returned by ?func=markov
+const markovSampleRunes = 1000
+
+// Training text used when ?func=markov is called without a corpus
+const defaultMarkovCorpus = "It was the best of times, it was the worst of times, it was the age of wisdom, " +
+	"it was the age of foolishness, it was the epoch of belief, it was the epoch of incredulity, " +
+	"it was the season of Light, it was the season of Darkness, it was the spring of hope, " +
+	"it was the winter of despair, we had everything before us, we had nothing before us, " +
+	"we were all going direct to Heaven, we were all going direct the other way."
+
+// Puzzle used when ?func=sudoku is called without one
+const defaultSudoku = "530070000600195000098000060800060003400803001700020006060000280000419005000080079"
+
+// handleCPUFunc runs one of the alternative workloads selected with
+// ?func= on /process/cpu-intensive. Each workload reads its parameters
+// from the query string and returns its result fields, or an error that
+// is reported to the client as a 400. The longer-running workloads stop
+// with ctx.Err() once the request's deadline passes.
+func handleCPUFunc(c *gin.Context, fn string) {
+	query := c.Request.URL.Query()
+	ctx := c.Request.Context()
+
+	startTime := time.Now()
+	cpuTimer := startCPUTimer(c)
+
+	var result gin.H
+	var err error
+	switch fn {
+	case "fft":
+		result, err = runFFT(ctx, query)
+	case "catalan":
+		result, err = runCatalan(query)
+	case "sudoku":
+		result, err = runSudoku(ctx, query)
+	case "perfect":
+		result, err = runPerfect(ctx, query)
+	case "josephus":
+		result, err = runJosephus(ctx, query)
+	case "pascal":
+		result, err = runPascal(ctx, query)
+	case "hanoi":
+		result, err = runHanoi(query)
+	case "dlog":
+		result, err = runDiscreteLog(ctx, query)
+	case "totient":
+		result, err = runTotient(ctx, query)
+	case "factorial":
+		result, err = runFactorial(query)
+	case "contfrac":
+		result, err = runContinuedFraction(query)
+	case "convexhull":
+		result, err = runConvexHull(query)
+	case "lucas":
+		result, err = runLucas(ctx, query)
+	case "markov":
+		result, err = runMarkov(ctx, query)
+	default:
+		err = fmt.Errorf("Unknown func: %s", fn)
+	}
+
+	if timedOut(c) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endTime := time.Now()
+	setServerTimingTotal(c, endTime.Sub(startTime))
+	result["func"] = fn
+	result["execution_time_seconds"] = endTime.Sub(startTime).Seconds()
+	result["execution_time_ns"] = endTime.Sub(startTime).Nanoseconds()
+	cpuTimer.record(result)
+	reportGCDisabled(c, result)
+	result["service"] = "Go Gin"
+
+	respond(c, http.StatusOK, result)
+}
+
+// queryInt reads an integer query parameter, returning def when it is absent.
+func queryInt(query url.Values, key string, def int) (int, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return def, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid %s: must be an integer", key)
+	}
+	return value, nil
+}
+
+// queryBool reads a boolean query parameter, which is false when absent.
+func queryBool(query url.Values, key string) (bool, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("Invalid %s: must be true or false", key)
+	}
+	return value, nil
+}
+
+// queryInt64 reads a 64-bit integer query parameter, returning def when it is absent.
+func queryInt64(query url.Values, key string, def int64) (int64, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return def, nil
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid %s: must be an integer", key)
+	}
+	return value, nil
+}
+
+func runFFT(ctx context.Context, query url.Values) (gin.H, error) {
+	size, err := queryInt(query, "size", 1024)
+	if err != nil {
+		return nil, err
+	}
+	if size < 1 || size&(size-1) != 0 {
+		return nil, fmt.Errorf("Invalid size: must be a power of two")
+	}
+	if size > maxFFTSize {
+		return nil, fmt.Errorf("Invalid size: must not exceed %d", maxFFTSize)
+	}
+	seed, err := queryInt64(query, "seed", config.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate seeded complex samples in [-1, 1)
+	rng := rand.New(rand.NewSource(seed))
+	samples := make([]complex128, size)
+	for i := range samples {
+		samples[i] = complex(rng.Float64()*2-1, rng.Float64()*2-1)
+	}
+
+	if fft(ctx, samples); ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	dominantBin := 0
+	dominantMagnitude := 0.0
+	for i, v := range samples {
+		if magnitude := cmplx.Abs(v); magnitude > dominantMagnitude {
+			dominantBin = i
+			dominantMagnitude = magnitude
+		}
+	}
+
+	return gin.H{
+		"size":               size,
+		"seed":               seed,
+		"dominant_bin":       dominantBin,
+		"dominant_magnitude": dominantMagnitude,
+	}, nil
+}
+
+// fft performs an in-place iterative radix-2 Cooley-Tukey FFT, stopping
+// between passes once ctx ends. len(x) must be a power of two.
+func fft(ctx context.Context, x []complex128) {
+	n := len(x)
+
+	// Bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	// Butterflies
+	for length := 2; length <= n; length <<= 1 {
+		if ctx.Err() != nil {
+			return
+		}
+		angle := -2 * math.Pi / float64(length)
+		wLen := complex(math.Cos(angle), math.Sin(angle))
+		for start := 0; start < n; start += length {
+			w := complex(1, 0)
+			half := length / 2
+			for k := 0; k < half; k++ {
+				u := x[start+k]
+				v := x[start+k+half] * w
+				x[start+k] = u + v
+				x[start+k+half] = u - v
+				w *= wLen
+			}
+		}
+	}
+}
+
+func runCatalan(query url.Values) (gin.H, error) {
+	n, err := queryInt(query, "n", 100)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > maxCatalanN {
+		return nil, fmt.Errorf("Invalid n: must be between 0 and %d", maxCatalanN)
+	}
+
+	// C(n) = binomial(2n, n) / (n + 1)
+	value := new(big.Int).Binomial(int64(2*n), int64(n))
+	value.Quo(value, big.NewInt(int64(n+1)))
+	digits := value.String()
+
+	return gin.H{
+		"n":      n,
+		"value":  digits,
+		"digits": len(digits),
+	}, nil
+}
+
+func runSudoku(ctx context.Context, query url.Values) (gin.H, error) {
+	puzzle := query.Get("puzzle")
+	if puzzle == "" {
+		puzzle = defaultSudoku
+	}
+	if len(puzzle) != 81 {
+		return nil, fmt.Errorf("Invalid puzzle: must be 81 characters")
+	}
+
+	s := &sudoku{ctx: ctx}
+	for i := 0; i < 81; i++ {
+		ch := puzzle[i]
+		switch {
+		case ch == '0' || ch == '.':
+		case ch >= '1' && ch <= '9':
+			if !s.place(i, int(ch-'0')) {
+				return nil, fmt.Errorf("Invalid puzzle: conflicting digit at position %d", i)
+			}
+		default:
+			return nil, fmt.Errorf("Invalid puzzle: unexpected character %q at position %d", ch, i)
+		}
+	}
+
+	if !s.solve() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if s.steps > maxSudokuSteps {
+			return nil, fmt.Errorf("Puzzle abandoned after %d backtracking steps", maxSudokuSteps)
+		}
+		return nil, fmt.Errorf("Puzzle has no solution")
+	}
+
+	solution := make([]byte, 81)
+	for i, v := range s.grid {
+		solution[i] = byte('0' + v)
+	}
+
+	return gin.H{
+		"puzzle":   puzzle,
+		"solution": string(solution),
+		"steps":    s.steps,
+	}, nil
+}
+
+// sudoku tracks a grid plus bitmasks of the digits used in every row,
+// column and box so candidates can be computed in constant time. The
+// search gives up once ctx ends.
+type sudoku struct {
+	ctx   context.Context
+	grid  [81]int
+	rows  [9]uint16
+	cols  [9]uint16
+	boxes [9]uint16
+	steps int
+}
+
+func (s *sudoku) place(i, digit int) bool {
+	r, c := i/9, i%9
+	b := (r/3)*3 + c/3
+	bit := uint16(1) << digit
+	if s.rows[r]&bit != 0 || s.cols[c]&bit != 0 || s.boxes[b]&bit != 0 {
+		return false
+	}
+	s.grid[i] = digit
+	s.rows[r] |= bit
+	s.cols[c] |= bit
+	s.boxes[b] |= bit
+	return true
+}
+
+func (s *sudoku) clear(i int) {
+	r, c := i/9, i%9
+	b := (r/3)*3 + c/3
+	bit := ^(uint16(1) << s.grid[i])
+	s.grid[i] = 0
+	s.rows[r] &= bit
+	s.cols[c] &= bit
+	s.boxes[b] &= bit
+}
+
+func (s *sudoku) candidates(i int) uint16 {
+	r, c := i/9, i%9
+	b := (r/3)*3 + c/3
+	return ^(s.rows[r] | s.cols[c] | s.boxes[b]) & 0x3FE
+}
+
+// solve fills the grid by always branching on the empty cell with the
+// fewest candidates, which prunes most of the search tree.
+func (s *sudoku) solve() bool {
+	best, bestCandidates, bestCount := -1, uint16(0), 10
+	for i := 0; i < 81; i++ {
+		if s.grid[i] != 0 {
+			continue
+		}
+		candidates := s.candidates(i)
+		count := bits.OnesCount16(candidates)
+		if count == 0 {
+			return false
+		}
+		if count < bestCount {
+			best, bestCandidates, bestCount = i, candidates, count
+			if count == 1 {
+				break
+			}
+		}
+	}
+	if best == -1 {
+		return true
+	}
+
+	for digit := 1; digit <= 9; digit++ {
+		if bestCandidates&(1<<digit) == 0 {
+			continue
+		}
+		s.steps++
+		if s.steps > maxSudokuSteps || canceled(s.ctx, s.steps) {
+			return false
+		}
+		s.place(best, digit)
+		if s.solve() {
+			return true
+		}
+		s.clear(best)
+	}
+	return false
+}
+
+func runPerfect(ctx context.Context, query url.Values) (gin.H, error) {
+	limit, err := queryInt(query, "limit", 10000)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > maxPerfectLimit {
+		return nil, fmt.Errorf("Invalid limit: must be between 1 and %d", maxPerfectLimit)
+	}
+
+	perfect := []int{}
+	for n := 2; n <= limit; n++ {
+		if canceled(ctx, n) {
+			return nil, ctx.Err()
+		}
+		if sumProperDivisors(n) == n {
+			perfect = append(perfect, n)
+		}
+	}
+
+	return gin.H{
+		"limit":           limit,
+		"perfect_numbers": perfect,
+		"count":           len(perfect),
+	}, nil
+}
+
+// sumProperDivisors returns the sum of the divisors of n excluding n itself.
+func sumProperDivisors(n int) int {
+	if n < 2 {
+		return 0
+	}
+	sum := 1
+	for i := 2; i*i <= n; i++ {
+		if n%i == 0 {
+			sum += i
+			if other := n / i; other != i {
+				sum += other
+			}
+		}
+	}
+	return sum
+}
+
+func runJosephus(ctx context.Context, query url.Values) (gin.H, error) {
+	n, err := queryInt(query, "n", 41)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > maxJosephusN {
+		return nil, fmt.Errorf("Invalid n: must be between 1 and %d", maxJosephusN)
+	}
+	k, err := queryInt(query, "k", 3)
+	if err != nil {
+		return nil, err
+	}
+	if k < 1 || k > maxJosephusK {
+		return nil, fmt.Errorf("Invalid k: must be between 1 and %d", maxJosephusK)
+	}
+
+	method := query.Get("method")
+	var survivor int
+	switch method {
+	case "", "recurrence":
+		method = "recurrence"
+		survivor = josephusRecurrence(ctx, n, k)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	case "fast":
+		survivor = josephusFast(n, k)
+	default:
+		return nil, fmt.Errorf("Invalid method: must be recurrence or fast")
+	}
+
+	return gin.H{
+		"n":      n,
+		"k":      k,
+		"method": method,
+		// Positions are reported 1-based
+		"survivor": survivor + 1,
+	}, nil
+}
+
+// josephusRecurrence applies J(i) = (J(i-1) + k) mod i in O(n), stopping
+// early once ctx ends.
+func josephusRecurrence(ctx context.Context, n, k int) int {
+	survivor := 0
+	for i := 2; i <= n; i++ {
+		if canceled(ctx, i) {
+			break
+		}
+		survivor = (survivor + k) % i
+	}
+	return survivor
+}
+
+// josephusFast eliminates a whole lap of n/k people per step, giving
+// O(k log n) steps when k is small relative to n.
+func josephusFast(n, k int) int {
+	if n == 1 {
+		return 0
+	}
+	if k == 1 {
+		return n - 1
+	}
+	if k > n {
+		return (josephusFast(n-1, k) + k) % n
+	}
+
+	removed := n / k
+	survivor := josephusFast(n-removed, k) - n%k
+	if survivor < 0 {
+		survivor += n
+	} else {
+		survivor += survivor / (k - 1)
+	}
+	return survivor
+}
+
+func runPascal(ctx context.Context, query url.Values) (gin.H, error) {
+	rows, err := queryInt(query, "rows", 100)
+	if err != nil {
+		return nil, err
+	}
+	if rows < 1 || rows > maxPascalRows {
+		return nil, fmt.Errorf("Invalid rows: must be between 1 and %d", maxPascalRows)
+	}
+
+	// Each row is built from the previous one
+	row := []*big.Int{big.NewInt(1)}
+	for r := 1; r < rows; r++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		next := make([]*big.Int, r+1)
+		next[0] = big.NewInt(1)
+		next[r] = big.NewInt(1)
+		for i := 1; i < r; i++ {
+			next[i] = new(big.Int).Add(row[i-1], row[i])
+		}
+		row = next
+	}
+
+	lastRow := make([]string, len(row))
+	for i, v := range row {
+		lastRow[i] = v.String()
+	}
+
+	return gin.H{
+		"rows":     rows,
+		"last_row": lastRow,
+	}, nil
+}
+
+// hanoiMove is a single step of a Tower of Hanoi solution.
+type hanoiMove struct {
+	Disk int    `json:"disk"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func runHanoi(query url.Values) (gin.H, error) {
+	disks, err := queryInt(query, "disks", 10)
+	if err != nil {
+		return nil, err
+	}
+	if disks < 1 || disks > maxHanoiDisks {
+		return nil, fmt.Errorf("Invalid disks: must be between 1 and %d", maxHanoiDisks)
+	}
+	withMoves, err := queryBool(query, "moves")
+	if err != nil {
+		return nil, err
+	}
+	if withMoves && disks > maxHanoiSequenceDisks {
+		return nil, fmt.Errorf("Invalid disks: move sequence is limited to %d disks", maxHanoiSequenceDisks)
+	}
+
+	// 2^N - 1
+	count := new(big.Int).Lsh(big.NewInt(1), uint(disks))
+	count.Sub(count, big.NewInt(1))
+
+	result := gin.H{
+		"disks":      disks,
+		"move_count": count.String(),
+	}
+	if withMoves {
+		moves := make([]hanoiMove, 0, 1<<disks-1)
+		result["moves"] = hanoi(disks, "A", "C", "B", moves)
+	}
+	return result, nil
+}
+
+// hanoi appends the moves that transfer n disks from one peg to another.
+func hanoi(n int, from, to, via string, moves []hanoiMove) []hanoiMove {
+	if n == 0 {
+		return moves
+	}
+	moves = hanoi(n-1, from, via, to, moves)
+	moves = append(moves, hanoiMove{Disk: n, From: from, To: to})
+	return hanoi(n-1, via, to, from, moves)
+}
+
+func runDiscreteLog(ctx context.Context, query url.Values) (gin.H, error) {
+	mod, err := queryInt64(query, "mod", 1000003)
+	if err != nil {
+		return nil, err
+	}
+	if mod < 2 || mod > maxDlogModulus {
+		return nil, fmt.Errorf("Invalid mod: must be between 2 and %d", int64(maxDlogModulus))
+	}
+	g, err := queryInt64(query, "g", 2)
+	if err != nil {
+		return nil, err
+	}
+	h, err := queryInt64(query, "h", 123456)
+	if err != nil {
+		return nil, err
+	}
+	if g < 0 || h < 0 {
+		return nil, fmt.Errorf("Invalid g or h: must be non-negative")
+	}
+
+	p := big.NewInt(mod)
+	base := new(big.Int).Mod(big.NewInt(g), p)
+	target := new(big.Int).Mod(big.NewInt(h), p)
+
+	// Baby steps: g^j for j in [0, m), keeping the smallest j per value
+	m := int64(math.Ceil(math.Sqrt(float64(mod))))
+	table := make(map[uint64]int64, m)
+	value := big.NewInt(1)
+	for j := int64(0); j < m; j++ {
+		if canceled(ctx, int(j)) {
+			return nil, ctx.Err()
+		}
+		if _, seen := table[value.Uint64()]; !seen {
+			table[value.Uint64()] = j
+		}
+		value.Mul(value, base).Mod(value, p)
+	}
+
+	// Giant steps: h * g^(-m*i)
+	inverse := new(big.Int).ModInverse(base, p)
+	if inverse == nil {
+		return nil, fmt.Errorf("Invalid g: must be coprime to mod")
+	}
+	factor := new(big.Int).Exp(inverse, big.NewInt(m), p)
+
+	result := gin.H{
+		"g":   g,
+		"h":   h,
+		"mod": mod,
+	}
+	gamma := new(big.Int).Set(target)
+	for i := int64(0); i < m; i++ {
+		if canceled(ctx, int(i)) {
+			return nil, ctx.Err()
+		}
+		if j, ok := table[gamma.Uint64()]; ok {
+			result["x"] = i*m + j
+			return result, nil
+		}
+		gamma.Mul(gamma, factor).Mod(gamma, p)
+	}
+
+	result["x"] = "no solution"
+	return result, nil
+}
+
+func runTotient(ctx context.Context, query url.Values) (gin.H, error) {
+	limit, err := queryInt(query, "limit", 100000)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > maxTotientLimit {
+		return nil, fmt.Errorf("Invalid limit: must be between 1 and %d", maxTotientLimit)
+	}
+
+	// Sieve: phi[n] starts at n and every prime p dividing n scales it by (1 - 1/p)
+	phi := make([]int32, limit+1)
+	for i := range phi {
+		if canceled(ctx, i) {
+			return nil, ctx.Err()
+		}
+		phi[i] = int32(i)
+	}
+	for p := 2; p <= limit; p++ {
+		if phi[p] != int32(p) {
+			continue // not prime
+		}
+		// Each prime crosses off limit/p multiples
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		for m := p; m <= limit; m += p {
+			phi[m] -= phi[m] / int32(p)
+		}
+	}
+
+	var sum int64
+	for n := 1; n <= limit; n++ {
+		sum += int64(phi[n])
+	}
+
+	return gin.H{
+		"limit":       limit,
+		"totient_sum": sum,
+	}, nil
+}
+
+func runFactorial(query url.Values) (gin.H, error) {
+	n, err := queryInt(query, "n", 1000)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > maxFactorialN {
+		return nil, fmt.Errorf("Invalid n: must be between 0 and %d", maxFactorialN)
+	}
+
+	digits := factorial(n).String()
+
+	digitSum := 0
+	for i := 0; i < len(digits); i++ {
+		digitSum += int(digits[i] - '0')
+	}
+
+	return gin.H{
+		"n":         n,
+		"digits":    len(digits),
+		"digit_sum": digitSum,
+	}, nil
+}
+
+// factorial returns n!. MulRange(1, 0) is the empty product, so 0! = 1
+// falls out naturally.
+func factorial(n int) *big.Int {
+	return new(big.Int).MulRange(1, int64(n))
+}
+
+// queryBigInt reads an arbitrary-precision integer query parameter.
+func queryBigInt(query url.Values, key string, def int64, maxDigits int) (*big.Int, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return big.NewInt(def), nil
+	}
+	if len(raw) > maxDigits {
+		return nil, fmt.Errorf("Invalid %s: must have at most %d digits", key, maxDigits)
+	}
+	value, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return nil, fmt.Errorf("Invalid %s: must be an integer", key)
+	}
+	return value, nil
+}
+
+func runContinuedFraction(query url.Values) (gin.H, error) {
+	num, err := queryBigInt(query, "num", 415, maxContFracDigits)
+	if err != nil {
+		return nil, err
+	}
+	den, err := queryBigInt(query, "den", 93, maxContFracDigits)
+	if err != nil {
+		return nil, err
+	}
+	if den.Sign() == 0 {
+		return nil, fmt.Errorf("Invalid den: must not be zero")
+	}
+
+	result := gin.H{
+		"num": num.String(),
+		"den": den.String(),
+	}
+
+	// Keep the denominator positive so Euclidean division floors
+	a, b := new(big.Int).Set(num), new(big.Int).Set(den)
+	if b.Sign() < 0 {
+		a.Neg(a)
+		b.Neg(b)
+	}
+
+	coefficients := []string{}
+	q, r := new(big.Int), new(big.Int)
+	for b.Sign() != 0 {
+		if len(coefficients) == maxContFracTerms {
+			return nil, fmt.Errorf("Expansion exceeds %d coefficients", maxContFracTerms)
+		}
+		q.DivMod(a, b, r)
+		coefficients = append(coefficients, q.String())
+		a, b, r = b, r, a
+	}
+
+	result["coefficients"] = coefficients
+	result["terms"] = len(coefficients)
+	return result, nil
+}
+
+type point struct{ X, Y float64 }
+
+func runConvexHull(query url.Values) (gin.H, error) {
+	n, err := queryInt(query, "points", 10000)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > maxHullPoints {
+		return nil, fmt.Errorf("Invalid points: must be between 1 and %d", maxHullPoints)
+	}
+	seed, err := queryInt64(query, "seed", config.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Seeded points in [0, 1000) x [0, 1000)
+	rng := rand.New(rand.NewSource(seed))
+	points := make([]point, n)
+	for i := range points {
+		points[i] = point{rng.Float64() * 1000, rng.Float64() * 1000}
+	}
+
+	hull := convexHull(points)
+
+	// Hull vertices come out in a fixed order, so this sum is reproducible
+	checksum := 0.0
+	for i, p := range hull {
+		checksum += float64(i+1) * (p.X + 2*p.Y)
+	}
+
+	return gin.H{
+		"points":        n,
+		"seed":          seed,
+		"hull_vertices": len(hull),
+		"checksum":      checksum,
+	}, nil
+}
+
+// convexHull returns the hull of points counter-clockwise, starting from
+// the lowest-x point, using Andrew's monotone chain. points is sorted in place.
+func convexHull(points []point) []point {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+		return points[i].Y < points[j].Y
+	})
+	if len(points) < 3 {
+		return points
+	}
+
+	cross := func(o, a, b point) float64 {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+
+	hull := make([]point, 0, 2*len(points))
+	// Lower hull
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	// Upper hull
+	upperStart := len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- {
+		p := points[i]
+		for len(hull) >= upperStart && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	// The last point repeats the first
+	return hull[:len(hull)-1]
+}
+
+func runLucas(ctx context.Context, query url.Values) (gin.H, error) {
+	n, err := queryInt(query, "n", 1000)
+	if err != nil {
+		return nil, err
+	}
+	// The first two terms default to the Lucas numbers; a=0, b=1 gives Fibonacci
+	a, err := queryInt64(query, "a", 2)
+	if err != nil {
+		return nil, err
+	}
+	b, err := queryInt64(query, "b", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	method := query.Get("method")
+	var value *big.Int
+	switch method {
+	case "", "matrix":
+		method = "matrix"
+		if n < 0 || n > maxLucasN {
+			return nil, fmt.Errorf("Invalid n: must be between 0 and %d", maxLucasN)
+		}
+		value = lucasMatrix(n, big.NewInt(a), big.NewInt(b))
+	case "naive":
+		if n < 0 || n > maxLucasNaiveN {
+			return nil, fmt.Errorf("Invalid n: must be between 0 and %d for the naive method", maxLucasNaiveN)
+		}
+		value = lucasNaive(ctx, n, big.NewInt(a), big.NewInt(b))
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Invalid method: must be matrix or naive")
+	}
+
+	digits := value.String()
+	return gin.H{
+		"n":      n,
+		"a":      a,
+		"b":      b,
+		"method": method,
+		"value":  digits,
+		"digits": len(strings.TrimPrefix(digits, "-")),
+	}, nil
+}
+
+// lucasNaive steps the recurrence x(i) = x(i-1) + x(i-2) n times, or
+// until ctx ends.
+func lucasNaive(ctx context.Context, n int, a, b *big.Int) *big.Int {
+	for i := 0; i < n; i++ {
+		if canceled(ctx, i) {
+			break
+		}
+		a.Add(a, b)
+		a, b = b, a
+	}
+	return a
+}
+
+// lucasMatrix raises Q = [[1 1] [1 0]] to the nth power by repeated
+// squaring, so it needs O(log n) big products instead of n additions.
+// Q^n = [[F(n+1) F(n)] [F(n) F(n-1)]], and x(n) = F(n)*b + F(n-1)*a.
+func lucasMatrix(n int, a, b *big.Int) *big.Int {
+	// Powers of Q are symmetric, so only three entries are tracked
+	m00, m01, m11 := big.NewInt(1), big.NewInt(0), big.NewInt(1)
+	q00, q01, q11 := big.NewInt(1), big.NewInt(1), big.NewInt(0)
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			m00, m01, m11 = symmetricMul(m00, m01, m11, q00, q01, q11)
+		}
+		if n > 1 {
+			q00, q01, q11 = symmetricMul(q00, q01, q11, q00, q01, q11)
+		}
+	}
+
+	result := new(big.Int).Mul(m01, b)
+	return result.Add(result, new(big.Int).Mul(m11, a))
+}
+
+// symmetricMul multiplies two commuting symmetric 2x2 matrices.
+func symmetricMul(x00, x01, x11, y00, y01, y11 *big.Int) (*big.Int, *big.Int, *big.Int) {
+	z00 := new(big.Int).Mul(x00, y00)
+	z00.Add(z00, new(big.Int).Mul(x01, y01))
+	z01 := new(big.Int).Mul(x00, y01)
+	z01.Add(z01, new(big.Int).Mul(x01, y11))
+	z11 := new(big.Int).Mul(x01, y01)
+	z11.Add(z11, new(big.Int).Mul(x11, y11))
+	return z00, z01, z11
+}
+
+func runMarkov(ctx context.Context, query url.Values) (gin.H, error) {
+	order, err := queryInt(query, "order", 3)
+	if err != nil {
+		return nil, err
+	}
+	if order < 1 || order > maxMarkovOrder {
+		return nil, fmt.Errorf("Invalid order: must be between 1 and %d", maxMarkovOrder)
+	}
+	length, err := queryInt(query, "length", 10000)
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 || length > maxMarkovLength {
+		return nil, fmt.Errorf("Invalid length: must be between 0 and %d", maxMarkovLength)
+	}
+	seed, err := queryInt64(query, "seed", config.Seed)
+	if err != nil {
+		return nil, err
+	}
+	corpus := query.Get("corpus")
+	if corpus == "" {
+		corpus = defaultMarkovCorpus
+	}
+	if len(corpus) > maxMarkovCorpus {
+		return nil, fmt.Errorf("Invalid corpus: must be at most %d bytes", maxMarkovCorpus)
+	}
+	runes := []rune(corpus)
+	if len(runes) <= order {
+		return nil, fmt.Errorf("Invalid corpus: must be longer than order")
+	}
+
+	// Followers are kept with repeats and in corpus order, so a uniform
+	// pick is frequency-weighted and the same seed gives the same text
+	trainStart := time.Now()
+	model := map[string][]rune{}
+	for i := order; i < len(runes); i++ {
+		context := string(runes[i-order : i])
+		model[context] = append(model[context], runes[i])
+	}
+	trainTime := time.Since(trainStart)
+
+	generateStart := time.Now()
+	rng := rand.New(rand.NewSource(seed))
+	generated := make([]rune, 0, length)
+	window := append([]rune{}, runes[:order]...)
+	restarts := 0
+	for i := 0; len(generated) < length; i++ {
+		if canceled(ctx, i) {
+			return nil, ctx.Err()
+		}
+		followers := model[string(window)]
+		if len(followers) == 0 {
+			// Only the corpus's final context can have no followers
+			window = append(window[:0], runes[:order]...)
+			restarts++
+			continue
+		}
+		next := followers[rng.Intn(len(followers))]
+		generated = append(generated, next)
+		window = append(window[1:], next)
+	}
+	generateTime := time.Since(generateStart)
+
+	sample := generated
+	if len(sample) > markovSampleRunes {
+		sample = sample[:markovSampleRunes]
+	}
+
+	return gin.H{
+		"order":                   order,
+		"length":                  length,
+		"seed":                    seed,
+		"corpus_length":           len(runes),
+		"contexts":                len(model),
+		"restarts":                restarts,
+		"sample":                  string(sample),
+		"training_time_seconds":   trainTime.Seconds(),
+		"generation_time_seconds": generateTime.Seconds(),
+	}, nil
+}
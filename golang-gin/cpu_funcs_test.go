@@ -0,0 +1,792 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"math/cmplx"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLucasMethodsAgree(t *testing.T) {
+	seeds := []struct {
+		name string
+		a, b int64
+	}{
+		{"lucas", 2, 1},
+		{"fibonacci", 0, 1},
+		{"negative", -3, 7},
+	}
+	for _, s := range seeds {
+		for _, n := range []int{0, 1, 2, 3, 10, 63, 64, 65, 100, 1000, 4097} {
+			t.Run(fmt.Sprintf("%s/%d", s.name, n), func(t *testing.T) {
+				naive := lucasNaive(context.Background(), n, big.NewInt(s.a), big.NewInt(s.b))
+				matrix := lucasMatrix(n, big.NewInt(s.a), big.NewInt(s.b))
+				if naive.Cmp(matrix) != 0 {
+					t.Errorf("naive = %s, matrix = %s", naive, matrix)
+				}
+			})
+		}
+	}
+}
+
+func TestRunLucas(t *testing.T) {
+	tests := []struct {
+		query string
+		value string
+	}{
+		{"n=0", "2"},
+		{"n=1", "1"},
+		{"n=10", "123"},
+		{"n=10&method=naive", "123"},
+		{"n=90&a=0&b=1", "2880067194370816120"},
+		{"n=90&a=0&b=1&method=naive", "2880067194370816120"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runLucas(context.Background(), query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result["value"] != tt.value {
+				t.Errorf("value = %v, want %s", result["value"], tt.value)
+			}
+		})
+	}
+}
+
+func TestSeededFuncsAreReproducible(t *testing.T) {
+	r := gin.New()
+	r.GET("/process/cpu-intensive", handleCPUIntensive)
+
+	tests := []struct {
+		query  string
+		fields []string
+	}{
+		{"func=fft&size=256", []string{"dominant_bin", "dominant_magnitude"}},
+		{"func=convexhull&points=500", []string{"hull_vertices", "checksum"}},
+		{"func=markov&length=500", []string{"sample", "restarts"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			get := func(query string) map[string]interface{} {
+				w := doRequest(r, http.MethodGet, "/process/cpu-intensive?"+query, "")
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d: %s", w.Code, w.Body)
+				}
+				return decodeJSON(t, w)
+			}
+			same := func(a, b map[string]interface{}) bool {
+				for _, field := range tt.fields {
+					if !reflect.DeepEqual(a[field], b[field]) {
+						return false
+					}
+				}
+				return true
+			}
+
+			first, second := get(tt.query+"&seed=42"), get(tt.query+"&seed=42")
+			if !same(first, second) {
+				t.Errorf("seed 42 gave %v, then %v", first, second)
+			}
+			if other := get(tt.query + "&seed=43"); same(first, other) {
+				t.Errorf("seeds 42 and 43 both gave %v", first)
+			}
+			// Without a seed the -seed default applies
+			if byDefault := get(tt.query); byDefault["seed"] != float64(config.Seed) || !same(byDefault, get(tt.query)) {
+				t.Errorf("default seed gave %v, want seed %d twice", byDefault, config.Seed)
+			}
+		})
+	}
+}
+
+func TestSudoku(t *testing.T) {
+	r := gin.New()
+	r.GET("/process/cpu-intensive", handleCPUIntensive)
+
+	// Arto Inkala's puzzle, billed as the hardest for human solvers
+	const hard = "800000000003600000070090200050007000000045700000100030001000068008500010090000400"
+	unsolvable := "123456780" + "000000009" + strings.Repeat("0", 63)
+	tests := []struct {
+		name   string
+		puzzle string
+		status int
+	}{
+		{"default puzzle", "", http.StatusOK},
+		{"dots for blanks", strings.ReplaceAll(defaultSudoku, "0", "."), http.StatusOK},
+		{"hard puzzle", hard, http.StatusOK},
+		{"empty grid", strings.Repeat("0", 81), http.StatusOK},
+		{"unsolvable", unsolvable, http.StatusBadRequest},
+		{"conflicting clues", "11" + strings.Repeat("0", 79), http.StatusBadRequest},
+		{"too short", defaultSudoku[:80], http.StatusBadRequest},
+		{"unexpected character", "x" + defaultSudoku[1:], http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := "func=sudoku"
+			if tt.puzzle != "" {
+				query += "&puzzle=" + tt.puzzle
+			}
+			w := doRequest(r, http.MethodGet, "/process/cpu-intensive?"+query, "")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			puzzle := tt.puzzle
+			if puzzle == "" {
+				puzzle = defaultSudoku
+			}
+			solution, _ := decodeJSON(t, w)["solution"].(string)
+			if len(solution) != 81 {
+				t.Fatalf("solution = %q, want 81 digits", solution)
+			}
+			for i := range puzzle {
+				if clue := puzzle[i]; clue != '0' && clue != '.' && solution[i] != clue {
+					t.Errorf("clue %c at %d changed to %c", clue, i, solution[i])
+				}
+			}
+			for unit := 0; unit < 9; unit++ {
+				var row, col, box []byte
+				for j := 0; j < 9; j++ {
+					row = append(row, solution[unit*9+j])
+					col = append(col, solution[j*9+unit])
+					box = append(box, solution[(unit/3*3+j/3)*9+unit%3*3+j%3])
+				}
+				for name, cells := range map[string][]byte{"row": row, "column": col, "box": box} {
+					sorted := append([]byte(nil), cells...)
+					sort.Slice(sorted, func(a, b int) bool { return sorted[a] < sorted[b] })
+					if string(sorted) != "123456789" {
+						t.Errorf("%s %d = %s, want the digits 1-9", name, unit, cells)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestJosephus(t *testing.T) {
+	// J(n) = 2(n - 2^⌊log₂n⌋) + 1 when every second person is eliminated
+	closedForm := func(n int) int {
+		return 2*(n-1<<(bits.Len(uint(n))-1)) + 1
+	}
+	for _, method := range []string{"recurrence", "fast"} {
+		for _, n := range []int{1, 2, 3, 5, 41, 64, 65, 1000, 123456, maxJosephusN} {
+			t.Run(fmt.Sprintf("%s/k=2/n=%d", method, n), func(t *testing.T) {
+				query, _ := url.ParseQuery(fmt.Sprintf("n=%d&k=2&method=%s", n, method))
+				result, err := runJosephus(context.Background(), query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result["survivor"] != closedForm(n) {
+					t.Errorf("survivor = %v, want %d", result["survivor"], closedForm(n))
+				}
+			})
+		}
+	}
+
+	tests := []struct {
+		query    string
+		survivor int
+		wantErr  bool
+	}{
+		// The original problem: 41 people, every third one
+		{"n=41&k=3", 31, false},
+		{"n=41&k=3&method=fast", 31, false},
+		{"n=7&k=1", 7, false},
+		{"n=5&k=1000&method=fast", 2, false},
+		{"n=0", 0, true},
+		{fmt.Sprintf("n=%d", maxJosephusN+1), 0, true},
+		{"n=10&k=0", 0, true},
+		{fmt.Sprintf("n=10&k=%d", maxJosephusK+1), 0, true},
+		{"n=ten", 0, true},
+		{"n=10&method=slow", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runJosephus(context.Background(), query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && result["survivor"] != tt.survivor {
+				t.Errorf("survivor = %v, want %d", result["survivor"], tt.survivor)
+			}
+		})
+	}
+}
+
+func TestFactorial(t *testing.T) {
+	for n, want := range map[int]string{0: "1", 1: "1", 5: "120", 20: "2432902008176640000", 25: "15511210043330985984000000"} {
+		if got := factorial(n).String(); got != want {
+			t.Errorf("%d! = %s, want %s", n, got, want)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		digits   int
+		digitSum int
+		wantErr  bool
+	}{
+		{"n=0", 1, 1, false},
+		{"n=1", 1, 1, false},
+		{"n=20", 19, 54, false},
+		{"n=100", 158, 648, false},
+		{fmt.Sprintf("n=%d", maxFactorialN), 456574, 0, false},
+		{fmt.Sprintf("n=%d", maxFactorialN+1), 0, 0, true},
+		{"n=-1", 0, 0, true},
+		{"n=many", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runFactorial(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result["digits"] != tt.digits {
+				t.Errorf("digits = %v, want %d", result["digits"], tt.digits)
+			}
+			if tt.digitSum != 0 && result["digit_sum"] != tt.digitSum {
+				t.Errorf("digit_sum = %v, want %d", result["digit_sum"], tt.digitSum)
+			}
+		})
+	}
+}
+
+func TestCPUWorkloadsStopAtTheDeadline(t *testing.T) {
+	setConfig(t, &config.MaxN, 100000)
+	setConfig(t, &config.MaxPrimeLimit, 10000000)
+	const timeout = 10 * time.Millisecond
+	r := gin.New()
+	r.Use(timeoutRequests(timeout))
+	r.GET("/process/cpu-intensive", handleCPUIntensive)
+	r.POST("/process/parallel", handleParallel)
+
+	// Each of these runs for a quarter of a second or more when left alone
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"josephus", http.MethodGet, "/process/cpu-intensive?func=josephus&n=100000000", ""},
+		{"perfect", http.MethodGet, "/process/cpu-intensive?func=perfect&limit=1000000", ""},
+		{"totient", http.MethodGet, "/process/cpu-intensive?func=totient&limit=10000000", ""},
+		{"dlog", http.MethodGet, "/process/cpu-intensive?func=dlog&mod=999999999989&h=5", ""},
+		{"trial division primes", http.MethodGet, "/process/cpu-intensive?n=10&prime_limit=10000000&algo=trial", ""},
+		{"big fibonacci", http.MethodGet, "/process/cpu-intensive?n=100000&prime_limit=2&algo=trial", ""},
+		{"parallel", http.MethodPost, "/process/parallel", `{"workers":8,"n":100000}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			w := doRequest(r, tt.method, tt.path, tt.body)
+			elapsed := time.Since(start)
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusServiceUnavailable, w.Body)
+			}
+			if elapsed > timeout+200*time.Millisecond {
+				t.Errorf("took %v, want it to stop soon after the %v deadline", elapsed, timeout)
+			}
+		})
+	}
+}
+
+func TestHanoi(t *testing.T) {
+	tests := []struct {
+		query     string
+		moveCount string
+		moves     int
+		wantErr   bool
+	}{
+		{"disks=3", "7", 0, false},
+		{"disks=3&moves=true", "7", 7, false},
+		{"disks=3&moves=1", "7", 7, false},
+		{"disks=3&moves=false", "7", 0, false},
+		{"disks=64", "18446744073709551615", 0, false},
+		{fmt.Sprintf("disks=%d&moves=true", maxHanoiSequenceDisks), fmt.Sprint(1<<maxHanoiSequenceDisks - 1), 1<<maxHanoiSequenceDisks - 1, false},
+		{fmt.Sprintf("disks=%d&moves=true", maxHanoiSequenceDisks+1), "", 0, true},
+		{"disks=3&moves=yes", "", 0, true},
+		{"disks=0", "", 0, true},
+		{fmt.Sprintf("disks=%d", maxHanoiDisks+1), "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runHanoi(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result["move_count"] != tt.moveCount {
+				t.Errorf("move_count = %v, want %s", result["move_count"], tt.moveCount)
+			}
+			moves, _ := result["moves"].([]hanoiMove)
+			if len(moves) != tt.moves {
+				t.Errorf("%d moves, want %d", len(moves), tt.moves)
+			}
+		})
+	}
+}
+
+func TestFFT(t *testing.T) {
+	// Direct O(n^2) discrete Fourier transform to check against
+	dft := func(x []complex128) []complex128 {
+		out := make([]complex128, len(x))
+		for k := range out {
+			for j, v := range x {
+				angle := -2 * math.Pi * float64(j*k) / float64(len(x))
+				out[k] += v * complex(math.Cos(angle), math.Sin(angle))
+			}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		input []complex128
+	}{
+		{"single", []complex128{3 - 1i}},
+		{"impulse", []complex128{1, 0, 0, 0}},
+		{"constant", []complex128{2, 2, 2, 2, 2, 2, 2, 2}},
+		{"mixed", []complex128{1, 2i, -3, 4 - 1i, 0.5, -2i, 7, 1 + 1i}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := dft(tt.input)
+			got := append([]complex128(nil), tt.input...)
+			fft(context.Background(), got)
+			for i := range want {
+				if cmplx.Abs(got[i]-want[i]) > 1e-9 {
+					t.Errorf("bin %d = %v, want %v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRunFFTRejectsBadSizes(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{"size=1", false},
+		{"size=1024", false},
+		{"size=0", true},
+		{"size=-8", true},
+		{"size=1000", true},
+		{fmt.Sprintf("size=%d", maxFFTSize*2), true},
+		{"size=abc", true},
+		{"seed=abc", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runFFT(context.Background(), query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if bin, _ := result["dominant_bin"].(int); !tt.wantErr && (bin < 0 || bin >= result["size"].(int)) {
+				t.Errorf("dominant_bin = %v outside size %v", result["dominant_bin"], result["size"])
+			}
+		})
+	}
+}
+
+func TestCatalan(t *testing.T) {
+	tests := []struct {
+		query   string
+		value   string
+		wantErr bool
+	}{
+		{"n=0", "1", false},
+		{"n=1", "1", false},
+		{"n=5", "42", false},
+		{"n=10", "16796", false},
+		{"n=30", "3814986502092304", false},
+		{"n=-1", "", true},
+		{fmt.Sprintf("n=%d", maxCatalanN+1), "", true},
+		{"n=abc", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runCatalan(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && result["value"] != tt.value {
+				t.Errorf("value = %v, want %s", result["value"], tt.value)
+			}
+		})
+	}
+
+	// C(n+1) = sum of C(i) * C(n-i)
+	catalan := []*big.Int{big.NewInt(1)}
+	for n := 0; n < 60; n++ {
+		next := new(big.Int)
+		for i := 0; i <= n; i++ {
+			next.Add(next, new(big.Int).Mul(catalan[i], catalan[n-i]))
+		}
+		catalan = append(catalan, next)
+	}
+	for n, want := range catalan {
+		result, err := runCatalan(url.Values{"n": {fmt.Sprint(n)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result["value"] != want.String() {
+			t.Errorf("C(%d) = %v, want %s", n, result["value"], want)
+		}
+	}
+}
+
+func TestPerfect(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    []int
+		wantErr bool
+	}{
+		{"limit=1", []int{}, false},
+		{"limit=6", []int{6}, false},
+		{"limit=27", []int{6}, false},
+		{"limit=28", []int{6, 28}, false},
+		{"limit=10000", []int{6, 28, 496, 8128}, false},
+		{"limit=0", nil, true},
+		{fmt.Sprintf("limit=%d", maxPerfectLimit+1), nil, true},
+		{"limit=abc", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runPerfect(context.Background(), query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := result["perfect_numbers"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("perfect_numbers = %v, want %v", got, tt.want)
+			}
+			if result["count"] != len(tt.want) {
+				t.Errorf("count = %v, want %d", result["count"], len(tt.want))
+			}
+		})
+	}
+}
+
+func TestPascal(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{"rows=1", []string{"1"}, false},
+		{"rows=2", []string{"1", "1"}, false},
+		{"rows=5", []string{"1", "4", "6", "4", "1"}, false},
+		{"rows=7", []string{"1", "6", "15", "20", "15", "6", "1"}, false},
+		{"rows=0", nil, true},
+		{fmt.Sprintf("rows=%d", maxPascalRows+1), nil, true},
+		{"rows=abc", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runPascal(context.Background(), query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(result["last_row"], tt.want) {
+				t.Errorf("last_row = %v, want %v", result["last_row"], tt.want)
+			}
+		})
+	}
+
+	// Row n holds the binomial coefficients C(n-1, k)
+	result, err := runPascal(context.Background(), url.Values{"rows": {"200"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, got := range result["last_row"].([]string) {
+		if want := new(big.Int).Binomial(199, int64(k)).String(); got != want {
+			t.Errorf("C(199, %d) = %s, want %s", k, got, want)
+		}
+	}
+}
+
+func TestDiscreteLog(t *testing.T) {
+	tests := []struct {
+		query   string
+		x       interface{}
+		wantErr bool
+	}{
+		{"mod=11&g=2&h=3", int64(8), false},
+		{"mod=11&g=2&h=1", int64(0), false},
+		{"mod=11&g=2&h=14", int64(8), false},
+		{"mod=7&g=2&h=3", "no solution", false},
+		{"mod=1000003&g=2&h=1024", int64(10), false},
+		{"mod=1", nil, true},
+		{fmt.Sprintf("mod=%d", int64(maxDlogModulus)+1), nil, true},
+		{"mod=11&g=-2", nil, true},
+		{"mod=11&h=-1", nil, true},
+		{"mod=7&g=14", nil, true},
+		{"mod=abc", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runDiscreteLog(context.Background(), query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && result["x"] != tt.x {
+				t.Errorf("x = %v, want %v", result["x"], tt.x)
+			}
+		})
+	}
+
+	// The smallest exponent, checked by brute force for every target
+	const mod, g = 101, 3
+	for h := int64(1); h < mod; h++ {
+		want := interface{}("no solution")
+		for x, v := int64(0), int64(1); x < mod; x, v = x+1, v*g%mod {
+			if v == h {
+				want = x
+				break
+			}
+		}
+		result, err := runDiscreteLog(context.Background(), url.Values{"mod": {fmt.Sprint(mod)}, "g": {fmt.Sprint(g)}, "h": {fmt.Sprint(h)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result["x"] != want {
+			t.Errorf("log_%d(%d) mod %d = %v, want %v", g, h, mod, result["x"], want)
+		}
+	}
+}
+
+func TestTotient(t *testing.T) {
+	// Euler's phi by counting coprimes directly
+	gcd := func(a, b int) int {
+		for b != 0 {
+			a, b = b, a%b
+		}
+		return a
+	}
+	phiSum := func(limit int) int64 {
+		var sum int64
+		for n := 1; n <= limit; n++ {
+			for k := 1; k <= n; k++ {
+				if gcd(n, k) == 1 {
+					sum++
+				}
+			}
+		}
+		return sum
+	}
+
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{"limit=1", false},
+		{"limit=2", false},
+		{"limit=10", false},
+		{"limit=97", false},
+		{"limit=500", false},
+		{"limit=0", true},
+		{fmt.Sprintf("limit=%d", maxTotientLimit+1), true},
+		{"limit=abc", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runTotient(context.Background(), query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := phiSum(result["limit"].(int)); result["totient_sum"] != want {
+				t.Errorf("totient_sum = %v, want %d", result["totient_sum"], want)
+			}
+		})
+	}
+}
+
+func TestContinuedFraction(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{"", []string{"4", "2", "6", "7"}, false},
+		{"num=1&den=1", []string{"1"}, false},
+		{"num=0&den=5", []string{"0"}, false},
+		{"num=10&den=4", []string{"2", "2"}, false},
+		{"num=-7&den=3", []string{"-3", "1", "2"}, false},
+		{"num=7&den=-3", []string{"-3", "1", "2"}, false},
+		{"num=832040&den=514229", []string{"1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "1", "2"}, false},
+		{"den=0", nil, true},
+		{"num=abc", nil, true},
+		{"den=1.5", nil, true},
+		{"num=" + strings.Repeat("9", maxContFracDigits+1), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runContinuedFraction(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(result["coefficients"], tt.want) || result["terms"] != len(tt.want) {
+				t.Errorf("coefficients = %v (%v terms), want %v", result["coefficients"], result["terms"], tt.want)
+			}
+		})
+	}
+}
+
+func TestConvexHull(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []point
+		want   []point
+	}{
+		{"single point", []point{{1, 1}}, []point{{1, 1}}},
+		{"two points", []point{{2, 0}, {0, 0}}, []point{{0, 0}, {2, 0}}},
+		{"triangle", []point{{0, 0}, {1, 2}, {2, 0}}, []point{{0, 0}, {2, 0}, {1, 2}}},
+		{"square with interior points", []point{{1, 1}, {0, 2}, {2, 2}, {0.5, 1.5}, {2, 0}, {0, 0}}, []point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}},
+		{"collinear edge points dropped", []point{{0, 0}, {1, 0}, {2, 0}, {2, 2}, {0, 2}}, []point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}},
+		{"all collinear", []point{{2, 2}, {0, 0}, {1, 1}}, []point{{0, 0}, {2, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convexHull(tt.points); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hull = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Every point lies on or to the left of each counter-clockwise edge
+	rng := rand.New(rand.NewSource(1))
+	points := make([]point, 1000)
+	for i := range points {
+		points[i] = point{rng.Float64() * 1000, rng.Float64() * 1000}
+	}
+	hull := convexHull(append([]point(nil), points...))
+	for i, a := range hull {
+		b := hull[(i+1)%len(hull)]
+		for _, p := range points {
+			if (b.X-a.X)*(p.Y-a.Y)-(b.Y-a.Y)*(p.X-a.X) < -1e-9 {
+				t.Fatalf("%v is outside hull edge %v-%v", p, a, b)
+			}
+		}
+	}
+}
+
+func TestRunConvexHullRejectsBadInput(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{"points=1", false},
+		{"points=1000&seed=7", false},
+		{"points=0", true},
+		{fmt.Sprintf("points=%d", maxHullPoints+1), true},
+		{"points=abc", true},
+		{"seed=abc", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runConvexHull(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if vertices, _ := result["hull_vertices"].(int); !tt.wantErr && (vertices < 1 || vertices > result["points"].(int)) {
+				t.Errorf("hull_vertices = %v for %v points", result["hull_vertices"], result["points"])
+			}
+		})
+	}
+}
+
+func TestMarkov(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		sample   string
+		restarts int
+		contexts int
+		wantErr  bool
+	}{
+		{"cycle", "order=1&length=6&corpus=abcd", "bcdbcd", 1, 3, false},
+		{"dead end", "order=2&length=5&corpus=aab", "bbbbb", 4, 1, false},
+		{"empty", "order=1&length=0&corpus=xy", "", 0, 1, false},
+		{"runes", "order=1&length=3&corpus=é€é€", "€é€", 0, 2, false},
+		{"order too small", "order=0", "", 0, 0, true},
+		{"order too large", fmt.Sprintf("order=%d", maxMarkovOrder+1), "", 0, 0, true},
+		{"negative length", "length=-1", "", 0, 0, true},
+		{"length too large", fmt.Sprintf("length=%d", maxMarkovLength+1), "", 0, 0, true},
+		{"corpus too short", "order=3&corpus=abc", "", 0, 0, true},
+		{"corpus too long", "corpus=" + strings.Repeat("a", maxMarkovCorpus+1), "", 0, 0, true},
+		{"bad seed", "seed=abc", "", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := runMarkov(context.Background(), query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result["sample"] != tt.sample || result["restarts"] != tt.restarts || result["contexts"] != tt.contexts {
+				t.Errorf("sample = %q, restarts = %v, contexts = %v, want %q, %d, %d", result["sample"], result["restarts"], result["contexts"], tt.sample, tt.restarts, tt.contexts)
+			}
+		})
+	}
+
+	// The sample is capped, and every step follows a context from the corpus
+	result, err := runMarkov(context.Background(), url.Values{"order": {"3"}, "length": {"5000"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := []rune(result["sample"].(string))
+	if len(sample) != markovSampleRunes {
+		t.Fatalf("sample has %d runes, want %d", len(sample), markovSampleRunes)
+	}
+	if result["restarts"] == 0 {
+		for i := 3; i < len(sample); i++ {
+			if !strings.Contains(defaultMarkovCorpus, string(sample[i-3:i+1])) {
+				t.Fatalf("%q at %d is not in the corpus", string(sample[i-3:i+1]), i)
+			}
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// threadCPUTime is unavailable on macOS, which has no RUSAGE_THREAD.
+func threadCPUTime() (time.Duration, bool) {
+	return 0, false
+}
+
+// processCPUTime returns the user+system CPU time consumed by the whole
+// process, across all threads.
+func processCPUTime() (time.Duration, bool) {
+	var usage unix.Rusage
+	if err := unix.Getrusage(unix.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package main
+
+import "time"
+
+// threadCPUTime is only implemented on Linux, which exposes per-thread
+// accounting through RUSAGE_THREAD.
+func threadCPUTime() (time.Duration, bool) {
+	return 0, false
+}
+
+// processCPUTime is only implemented on Linux and macOS.
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
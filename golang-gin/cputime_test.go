@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCPUTimeFields(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/cpu-intensive", cpuTimeGate(), handleCPUIntensive)
+
+	type timings struct {
+		ExecutionSeconds float64 `json:"execution_time_seconds"`
+		ExecutionNS      *int64  `json:"execution_time_ns"`
+		CPUNS            *int64  `json:"cpu_time_ns"`
+		ProcessCPUNS     *int64  `json:"process_cpu_time_ns"`
+	}
+	run := func(t *testing.T, body string, cpuTime bool) timings {
+		req := httptest.NewRequest(http.MethodPost, "/process/cpu-intensive", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if cpuTime {
+			req.Header.Set(cpuTimeHeader, "true")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body)
+		}
+		var resp timings
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.ExecutionNS == nil || *resp.ExecutionNS <= 0 {
+			t.Fatalf("execution_time_ns = %v, want a positive duration", resp.ExecutionNS)
+		}
+		// The seconds field is kept and agrees with the nanoseconds
+		if math.Abs(resp.ExecutionSeconds*1e9-float64(*resp.ExecutionNS)) > 1000 {
+			t.Errorf("execution_time_seconds = %v, execution_time_ns = %d", resp.ExecutionSeconds, *resp.ExecutionNS)
+		}
+		return resp
+	}
+
+	t.Run("without the header", func(t *testing.T) {
+		resp := run(t, `{"n":10}`, false)
+		if resp.CPUNS != nil || resp.ProcessCPUNS != nil {
+			t.Errorf("CPU time reported without %s", cpuTimeHeader)
+		}
+	})
+
+	t.Run("with the header", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("per-thread CPU time is only measured on Linux")
+		}
+		light := run(t, `{"n":10,"prime_limit":1000}`, true)
+		heavy := run(t, `{"n":10,"prime_limit":5000000}`, true)
+		for _, resp := range []timings{light, heavy} {
+			if resp.CPUNS == nil || *resp.CPUNS < 0 || resp.ProcessCPUNS == nil || *resp.ProcessCPUNS < 0 {
+				t.Fatalf("cpu_time_ns = %v, process_cpu_time_ns = %v, want non-negative durations", resp.CPUNS, resp.ProcessCPUNS)
+			}
+		}
+		// More work takes longer by every measure
+		if *heavy.ExecutionNS <= *light.ExecutionNS || *heavy.CPUNS <= *light.CPUNS || *heavy.ProcessCPUNS <= *light.ProcessCPUNS {
+			t.Errorf("light run took %d/%d/%d ns, heavy run %d/%d/%d ns (wall/thread/process)",
+				*light.ExecutionNS, *light.CPUNS, *light.ProcessCPUNS, *heavy.ExecutionNS, *heavy.CPUNS, *heavy.ProcessCPUNS)
+		}
+	})
+}
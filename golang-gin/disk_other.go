@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package main
+
+// diskUsage is only implemented on Linux and macOS. The other BSDs have
+// statfs too, but their Statfs_t fields differ in name and type.
+func diskUsage(path string) (total, free uint64, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+)
+
+// respond sends obj as msgpack when the client's Accept header prefers it,
+// and as JSON otherwise (including when no Accept header is sent).
+func respond(c *gin.Context, code int, obj interface{}) {
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEMSGPACK, binding.MIMEMSGPACK2) {
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		c.Render(code, render.MsgPack{Data: obj})
+	default:
+		c.JSON(code, obj)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+)
+
+func TestRespondNegotiatesEncoding(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/normal", handleNormalWork)
+
+	decodeMsgPack := func(data []byte, v interface{}) error {
+		return codec.NewDecoderBytes(data, new(codec.MsgpackHandle)).Decode(v)
+	}
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		decode      func([]byte, interface{}) error
+	}{
+		{"no Accept header", "", "application/json", json.Unmarshal},
+		{"json", "application/json", "application/json", json.Unmarshal},
+		{"msgpack", "application/msgpack", "application/msgpack", decodeMsgPack},
+		{"x-msgpack", "application/x-msgpack", "application/msgpack", decodeMsgPack},
+		{"msgpack preferred", "application/msgpack, application/json;q=0.5", "application/msgpack", decodeMsgPack},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"name":"Ada Lovelace","birthdate":"1990-05-15","email":"ada@example.com"}`
+			req := httptest.NewRequest(http.MethodPost, "/process/normal", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.contentType) {
+				t.Errorf("Content-Type = %s, want %s", contentType, tt.contentType)
+			}
+
+			var resp struct {
+				FirstName string `json:"first_name" codec:"first_name"`
+				Username  string `json:"username" codec:"username"`
+				IsAdult   bool   `json:"is_adult" codec:"is_adult"`
+			}
+			if err := tt.decode(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding: %v", err)
+			}
+			if resp.FirstName != "Ada" || resp.Username != "ada" || !resp.IsAdult {
+				t.Errorf("decoded %+v", resp)
+			}
+		})
+	}
+}
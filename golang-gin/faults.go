@@ -0,0 +1,38 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Longest inject_latency_ms accepted in the settings
+const maxInjectedLatencyMS = 60000
+
+// injectFaults delays each request by the inject_latency_ms setting and
+// then fails error_rate of them with a 500, following the settings across
+// reloads. A request whose deadline passes during the delay gets the usual
+// timeout response.
+func injectFaults() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings := config.Settings()
+		if settings.InjectLatencyMS > 0 {
+			timer := time.NewTimer(time.Duration(settings.InjectLatencyMS) * time.Millisecond)
+			select {
+			case <-timer.C:
+			case <-c.Request.Context().Done():
+				timer.Stop()
+			}
+			if timedOut(c) {
+				return
+			}
+		}
+		if settings.ErrorRate > 0 && rand.Float64() < settings.ErrorRate {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Injected error"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInjectFaults(t *testing.T) {
+	old := config.Settings()
+	t.Cleanup(func() {
+		config.mu.Lock()
+		config.settings = old
+		config.mu.Unlock()
+	})
+
+	tests := []struct {
+		name      string
+		latencyMS int
+		errorRate float64
+		timeout   time.Duration
+		status    int
+	}{
+		{"off", 0, 0, 0, http.StatusOK},
+		{"latency", 50, 0, 0, http.StatusOK},
+		{"every request fails", 0, 1, 0, http.StatusInternalServerError},
+		{"latency then failure", 50, 1, 0, http.StatusInternalServerError},
+		{"latency past the deadline", 500, 0, 50 * time.Millisecond, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.mu.Lock()
+			config.settings.InjectLatencyMS, config.settings.ErrorRate = tt.latencyMS, tt.errorRate
+			config.mu.Unlock()
+
+			r := gin.New()
+			r.Use(timeoutRequests(tt.timeout), injectFaults())
+			r.GET("/process/normal", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			start := time.Now()
+			w := doRequest(r, http.MethodGet, "/process/normal", "")
+			elapsed := time.Since(start)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			wait := time.Duration(tt.latencyMS) * time.Millisecond
+			if tt.timeout > 0 {
+				wait = tt.timeout
+			}
+			if elapsed < wait || elapsed > wait+200*time.Millisecond {
+				t.Errorf("took %v, want about %v", elapsed, wait)
+			}
+		})
+	}
+
+	t.Run("partial error rate", func(t *testing.T) {
+		config.mu.Lock()
+		config.settings.InjectLatencyMS, config.settings.ErrorRate = 0, 0.5
+		config.mu.Unlock()
+
+		r := gin.New()
+		r.Use(injectFaults())
+		r.GET("/process/normal", func(c *gin.Context) { c.Status(http.StatusOK) })
+		failed := 0
+		for i := 0; i < 1000; i++ {
+			if doRequest(r, http.MethodGet, "/process/normal", "").Code == http.StatusInternalServerError {
+				failed++
+			}
+		}
+		// Far outside the binomial spread of 1000 draws at 0.5
+		if failed < 400 || failed > 600 {
+			t.Errorf("%d of 1000 requests failed, want about 500", failed)
+		}
+	})
+}
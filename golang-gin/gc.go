@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Clients opt in to GC-free measurement with this header
+const disableGCHeader = "X-Disable-GC"
+
+const gcDisabledKey = "gc_disabled"
+
+// gcDisableSlot admits a single GC-disabled request at a time. The GC
+// percent is process-wide, so while a request holds it every concurrent
+// request also allocates without collection; letting several overlap
+// makes an unbounded heap far more likely.
+var gcDisableSlot = make(chan struct{}, 1)
+
+// disableGC turns off the garbage collector for the duration of a request
+// that sets disableGCHeader, when allowed by -allow-gc-disable. A second
+// such request arriving while one is running gets a 429.
+func disableGC() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested, _ := strconv.ParseBool(c.GetHeader(disableGCHeader))
+		if !requested || !config.AllowGCDisable {
+			c.Next()
+			return
+		}
+
+		select {
+		case gcDisableSlot <- struct{}{}:
+		default:
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Another GC-disabled request is in progress"})
+			return
+		}
+		defer func() { <-gcDisableSlot }()
+
+		previous := debug.SetGCPercent(-1)
+		defer debug.SetGCPercent(previous)
+
+		c.Set(gcDisabledKey, true)
+		c.Next()
+	}
+}
+
+// reportGCDisabled marks result when the request ran with the GC off.
+func reportGCDisabled(c *gin.Context, result gin.H) {
+	if c.GetBool(gcDisabledKey) {
+		result["gc_disabled"] = true
+	}
+}
+
+// handleGCStats reports the garbage collector's counters, for lining up
+// latency spikes in a benchmark with collections.
+func handleGCStats(c *gin.Context) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	c.JSON(http.StatusOK, gcStats(&stats))
+}
+
+// handleForceGC runs a full collection and reports how long it took,
+// along with the counters afterwards.
+func handleForceGC(c *gin.Context) {
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	startTime := time.Now()
+	runtime.GC()
+	elapsed := time.Since(startTime)
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	result := gcStats(&after)
+	result["num_gc_before"] = before.NumGC
+	result["heap_inuse_bytes_before"] = before.HeapInuse
+	result["gc_duration_seconds"] = elapsed.Seconds()
+	result["gc_duration_ns"] = elapsed.Nanoseconds()
+	c.JSON(http.StatusOK, result)
+}
+
+func gcStats(stats *runtime.MemStats) gin.H {
+	result := gin.H{
+		"num_gc":           stats.NumGC,
+		"num_forced_gc":    stats.NumForcedGC,
+		"pause_total_ns":   stats.PauseTotalNs,
+		"heap_inuse_bytes": stats.HeapInuse,
+		"heap_alloc_bytes": stats.HeapAlloc,
+		"next_gc_bytes":    stats.NextGC,
+		"gc_cpu_fraction":  stats.GCCPUFraction,
+		"service":          "Go Gin",
+	}
+	if stats.NumGC > 0 {
+		// PauseNs is a ring buffer with the latest pause at (NumGC+255)%256
+		result["last_pause_ns"] = stats.PauseNs[(stats.NumGC+255)%256]
+		result["last_gc"] = time.Unix(0, int64(stats.LastGC)).UTC().Format(time.RFC3339Nano)
+	}
+	return result
+}
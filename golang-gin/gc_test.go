@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGCEndpoints(t *testing.T) {
+	r := gin.New()
+	r.GET("/gc", handleGCStats)
+	r.POST("/gc", handleForceGC)
+
+	numGC := func(t *testing.T) float64 {
+		t.Helper()
+		w := doRequest(r, http.MethodGet, "/gc", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /gc: status = %d, want %d", w.Code, http.StatusOK)
+		}
+		n, _ := decodeJSON(t, w)["num_gc"].(float64)
+		return n
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		fields []string
+	}{
+		{"report", http.MethodGet, []string{"num_gc", "pause_total_ns", "heap_inuse_bytes", "last_pause_ns"}},
+		{"forced collection", http.MethodPost, []string{"num_gc", "num_gc_before", "pause_total_ns", "heap_inuse_bytes", "last_pause_ns", "gc_duration_ns"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := numGC(t)
+			w := doRequest(r, tt.method, "/gc", "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			body := decodeJSON(t, w)
+			for _, field := range tt.fields {
+				if _, ok := body[field]; !ok {
+					t.Errorf("%s missing from %v", field, body)
+				}
+			}
+			if tt.method != http.MethodPost {
+				return
+			}
+			if after, _ := body["num_gc"].(float64); after <= before {
+				t.Errorf("num_gc = %v after a forced GC, want more than %v", after, before)
+			}
+			if n := numGC(t); n <= before {
+				t.Errorf("GET /gc num_gc = %v after a forced GC, want more than %v", n, before)
+			}
+		})
+	}
+}
+
+func TestDisableGC(t *testing.T) {
+	// gcPercent reads the current setting without changing it
+	gcPercent := func() int {
+		percent := debug.SetGCPercent(-1)
+		debug.SetGCPercent(percent)
+		return percent
+	}
+	original := gcPercent()
+
+	inside := make(chan struct{})
+	release := make(chan struct{})
+	r := gin.New()
+	r.Use(disableGC())
+	r.GET("/work", func(c *gin.Context) {
+		result := gin.H{"gc_percent": gcPercent()}
+		reportGCDisabled(c, result)
+		c.JSON(http.StatusOK, result)
+	})
+	r.GET("/block", func(c *gin.Context) {
+		inside <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		allowed  bool
+		header   string
+		disabled bool
+	}{
+		{"requested", true, "true", true},
+		{"not requested", true, "", false},
+		{"not a boolean", true, "please", false},
+		{"not allowed", false, "1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, &config.AllowGCDisable, tt.allowed)
+			req := httptest.NewRequest(http.MethodGet, "/work", nil)
+			if tt.header != "" {
+				req.Header.Set(disableGCHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			body := decodeJSON(t, w)
+			if disabled := body["gc_disabled"] == true; disabled != tt.disabled {
+				t.Errorf("gc_disabled = %v, want %v", body["gc_disabled"], tt.disabled)
+			}
+			if off := body["gc_percent"] == float64(-1); off != tt.disabled {
+				t.Errorf("GC percent during the request = %v", body["gc_percent"])
+			}
+			if percent := gcPercent(); percent != original {
+				t.Errorf("GC percent after the request = %d, want %d", percent, original)
+			}
+		})
+	}
+
+	// Only one GC-disabled request at a time
+	setConfig(t, &config.AllowGCDisable, true)
+	blocked := httptest.NewRequest(http.MethodGet, "/block", nil)
+	blocked.Header.Set(disableGCHeader, "true")
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, blocked)
+		done <- w.Code
+	}()
+	<-inside
+
+	second := httptest.NewRequest(http.MethodGet, "/work", nil)
+	second.Header.Set(disableGCHeader, "true")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, second)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("overlapping request: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	close(release)
+	if status := <-done; status != http.StatusOK {
+		t.Errorf("first request: status = %d, want %d", status, http.StatusOK)
+	}
+	if percent := gcPercent(); percent != original {
+		t.Errorf("GC percent afterwards = %d, want %d", percent, original)
+	}
+}
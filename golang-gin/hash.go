@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt itself accepts costs up to 31, but every step doubles the work and
+// a single hash at cost 31 takes days, so requests are held well below that.
+const maxBcryptCost = 16
+
+type HashRequest struct {
+	Algorithm string `json:"algorithm" binding:"required"`
+	Text      string `json:"text"`
+
+	// bcrypt work factor (default 10)
+	Cost int `json:"cost"`
+}
+
+// handleHash digests Text with SHA-256 or hashes it with bcrypt, the two
+// ends of the cost spectrum for hashing in web services.
+func handleHash(c *gin.Context) {
+	var req HashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := gin.H{"algorithm": req.Algorithm}
+
+	startTime := time.Now()
+	cpuTimer := startCPUTimer(c)
+
+	switch req.Algorithm {
+	case "sha256":
+		sum := sha256.Sum256([]byte(req.Text))
+		result["hash"] = hex.EncodeToString(sum[:])
+
+	case "bcrypt":
+		if req.Cost == 0 {
+			req.Cost = bcrypt.DefaultCost
+		}
+		if req.Cost < bcrypt.MinCost || req.Cost > maxBcryptCost {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid cost: must be between %d and %d", bcrypt.MinCost, maxBcryptCost)})
+			return
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Text), req.Cost)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		result["hash"] = string(hash)
+		result["cost"] = req.Cost
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown algorithm: " + req.Algorithm})
+		return
+	}
+
+	endTime := time.Now()
+	setServerTimingTotal(c, endTime.Sub(startTime))
+	result["execution_time_seconds"] = endTime.Sub(startTime).Seconds()
+	cpuTimer.record(result)
+	reportGCDisabled(c, result)
+	result["service"] = "Go Gin"
+
+	c.JSON(http.StatusOK, result)
+}
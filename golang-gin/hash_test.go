@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHash(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/hash", handleHash)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		check  func(t *testing.T, resp map[string]interface{})
+	}{
+		{"sha256", `{"algorithm":"sha256","text":"abc"}`, http.StatusOK, func(t *testing.T, resp map[string]interface{}) {
+			if want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; resp["hash"] != want {
+				t.Errorf("hash = %v, want %s", resp["hash"], want)
+			}
+		}},
+		{"bcrypt", `{"algorithm":"bcrypt","text":"abc","cost":4}`, http.StatusOK, func(t *testing.T, resp map[string]interface{}) {
+			hash, _ := resp["hash"].(string)
+			if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte("abc")); err != nil {
+				t.Errorf("hash %q does not match the text: %v", hash, err)
+			}
+			if resp["cost"] != 4.0 {
+				t.Errorf("cost = %v, want 4", resp["cost"])
+			}
+		}},
+		{"bcrypt cost too high", `{"algorithm":"bcrypt","text":"abc","cost":17}`, http.StatusBadRequest, nil},
+		{"unknown algorithm", `{"algorithm":"md5","text":"abc"}`, http.StatusBadRequest, func(t *testing.T, resp map[string]interface{}) {
+			if resp["error"] != "Unknown algorithm: md5" {
+				t.Errorf("error = %v", resp["error"])
+			}
+		}},
+		{"missing algorithm", `{"text":"abc"}`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/hash", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.check != nil {
+				tt.check(t, decodeJSON(t, w))
+			}
+		})
+	}
+}
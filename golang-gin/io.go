@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IORequest struct {
+	// How long to wait, standing in for a database or upstream call
+	DelayMS int `json:"delay_ms"`
+}
+
+// handleIO holds the request open for delay_ms without using the CPU, so
+// many concurrent idle requests can be measured. The wait ends early if the
+// request's context does.
+func handleIO(c *gin.Context) {
+	var req IORequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.DelayMS < 0 || req.DelayMS > config.MaxDelayMS {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid delay_ms: must be between 0 and %d", config.MaxDelayMS)})
+		return
+	}
+
+	startTime := time.Now()
+	timer := time.NewTimer(time.Duration(req.DelayMS) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.Request.Context().Done():
+		timedOut(c)
+		return
+	}
+	actual := time.Since(startTime)
+	setServerTimingTotal(c, actual)
+
+	c.JSON(http.StatusOK, gin.H{
+		"requested_delay_ms":     req.DelayMS,
+		"actual_delay_ms":        float64(actual) / float64(time.Millisecond),
+		"execution_time_seconds": actual.Seconds(),
+		"service":                "Go Gin",
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIODelay(t *testing.T) {
+	setConfig(t, &config.MaxDelayMS, 100)
+	r := gin.New()
+	r.POST("/process/io", handleIO)
+
+	// Timers never fire early but can fire late on a busy machine
+	const tolerance = 50.0
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		delay  float64
+	}{
+		{"no delay", `{"delay_ms":0}`, http.StatusOK, 0},
+		{"short delay", `{"delay_ms":20}`, http.StatusOK, 20},
+		{"at the cap", `{"delay_ms":100}`, http.StatusOK, 100},
+		{"above the cap", `{"delay_ms":101}`, http.StatusBadRequest, 0},
+		{"negative", `{"delay_ms":-1}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/io", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			actual := decodeJSON(t, w)["actual_delay_ms"].(float64)
+			if actual < tt.delay || actual > tt.delay+tolerance {
+				t.Errorf("actual_delay_ms = %.3f, want within %.0fms above %.0f", actual, tolerance, tt.delay)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JSONWorkRequest struct {
+	// Number of records to round-trip (default 1000, capped by -max-json-records)
+	Count int `json:"count"`
+}
+
+// jsonRecord is one synthetic row for /process/json, with a mix of field types.
+type jsonRecord struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Score     float64   `json:"score"`
+	Active    bool      `json:"active"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handleJSONWork marshals count synthetic records with encoding/json and
+// decodes them again, timing each direction separately.
+func handleJSONWork(c *gin.Context) {
+	var req JSONWorkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.Count < 1 || req.Count > config.MaxJSONRecords {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid count: must be between 1 and %d", config.MaxJSONRecords)})
+		return
+	}
+
+	startTime := time.Now()
+	cpuTimer := startCPUTimer(c)
+
+	// Fixed epoch so the serialized size depends only on count
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tags := []string{"alpha", "beta", "gamma", "delta"}
+	records := make([]jsonRecord, req.Count)
+	for i := range records {
+		id := strconv.Itoa(i)
+		records[i] = jsonRecord{
+			ID:        i,
+			Name:      "User " + id,
+			Email:     "user" + id + "@example.com",
+			Score:     float64(i%1000) / 7,
+			Active:    i%3 != 0,
+			Tags:      tags[:1+i%len(tags)],
+			CreatedAt: epoch.Add(time.Duration(i) * time.Minute),
+		}
+	}
+
+	marshalStart := time.Now()
+	data, err := json.Marshal(records)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	unmarshalStart := time.Now()
+	var decoded []jsonRecord
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	endTime := time.Now()
+	setServerTimingTotal(c, endTime.Sub(startTime))
+
+	if len(decoded) != len(records) || decoded[len(decoded)-1].Email != records[len(records)-1].Email {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decoded records do not match"})
+		return
+	}
+
+	result := gin.H{
+		"count":                   req.Count,
+		"serialized_bytes":        len(data),
+		"marshal_time_seconds":    unmarshalStart.Sub(marshalStart).Seconds(),
+		"unmarshal_time_seconds":  endTime.Sub(unmarshalStart).Seconds(),
+		"round_trip_time_seconds": endTime.Sub(marshalStart).Seconds(),
+		"execution_time_seconds":  endTime.Sub(startTime).Seconds(),
+		"service":                 "Go Gin",
+	}
+	cpuTimer.record(result)
+	reportGCDisabled(c, result)
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJSONWork(t *testing.T) {
+	setConfig(t, &config.MaxJSONRecords, 50)
+	r := gin.New()
+	r.POST("/process/json", handleJSONWork)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"small count", `{"count":5}`, http.StatusOK},
+		{"at the cap", `{"count":50}`, http.StatusOK},
+		{"above the cap", `{"count":51}`, http.StatusBadRequest},
+		{"negative count", `{"count":-1}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/json", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			resp := decodeJSON(t, w)
+			if n, _ := resp["serialized_bytes"].(float64); n <= 0 {
+				t.Errorf("serialized_bytes = %v, want a positive number", resp["serialized_bytes"])
+			}
+			for _, key := range []string{"marshal_time_seconds", "unmarshal_time_seconds", "round_trip_time_seconds", "execution_time_seconds"} {
+				if _, ok := resp[key].(float64); !ok {
+					t.Errorf("%s = %v, want a duration", key, resp[key])
+				}
+			}
+		})
+	}
+}
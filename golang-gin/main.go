@@ -1,15 +1,56 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"math"
+	"math/big"
+	"math/bits"
 	"net/http"
+	"net/mail"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// Earliest birth year accepted by the normal work endpoint
+const minBirthYear = 1900
+
+// Format of the normal work endpoint's birthdate field
+const birthdateLayout = "2006-01-02"
+
+// Largest n whose Fibonacci number fits in an int: F(92) on 64-bit
+// platforms and F(46) on 32-bit ones. Above it the result is computed
+// with math/big and returned as a decimal string in fibonacci_result_big.
+const maxIntFibonacciN = 46 + 46*(bits.UintSize/64)
+
+// Prime limit used by the CPU-intensive endpoint when none is given
+const defaultPrimeLimit = 10000
+
+// Prime-finding algorithms selectable with ?algo= on the CPU-intensive
+// endpoint. Each stops early once ctx ends, so callers must check ctx
+// before using the primes.
+var primeAlgorithms = map[string]func(ctx context.Context, limit int) []int{
+	"sieve": eratosthenesPrimes,
+	"trial": findPrimes,
+	"atkin": atkinPrimes,
+}
+
 // Request models
 type NormalWorkRequest struct {
 	Name      string                 `json:"name" binding:"required"`
@@ -19,34 +60,205 @@ type NormalWorkRequest struct {
 }
 
 type CPUIntensiveRequest struct {
-	N int `json:"n"`
+	N int `json:"n" binding:"min=0"`
+
+	// Upper bound of the prime phase (default 10000, capped by -max-prime-limit)
+	PrimeLimit int `json:"prime_limit"`
+
+	// Reject n above maxIntFibonacciN instead of switching to math/big
+	IntOnly bool `json:"int_only"`
 }
 
 type StringProcessRequest struct {
-	Text      string `json:"text" binding:"required"`
+	Text      string `json:"text"`
 	Operation string `json:"operation"`
+
+	// Second input for operations that compare two texts
+	Text2 string `json:"text2"`
+
+	// csv_stats: number of rows allowed to differ from the header's column count
+	RaggedTolerance int `json:"ragged_tolerance"`
+
+	// wordbreak: words to segment with instead of the embedded dictionary
+	Dictionary []string `json:"dictionary"`
+
+	// jaccard: shingle length in characters (default 3)
+	// simhash: shingle length in words (default 2)
+	ShingleSize int `json:"shingle_size"`
+
+	// tfidf: separator between documents (default: blank lines)
+	Delimiter string `json:"delimiter"`
+
+	// Search pattern for fuzzy_match, regex and boyermoore
+	Pattern string `json:"pattern"`
+
+	// spellcheck, fuzzy_match: largest edit distance accepted (default 2)
+	MaxDistance int `json:"max_distance"`
+
+	// checksum: crc32 (default), crc32c, crc64 or crc64-ecma
+	Variant string `json:"variant"`
+
+	// concatenate: times to repeat Text (default 10, fewer for texts over
+	// 100KB, but at least 1)
+	Iterations int `json:"iterations"`
 }
 
 func main() {
+	if err := loadConfig(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
 	// Set Gin to release mode for production
 	gin.SetMode(gin.ReleaseMode)
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(requestID(), accessLog(), gin.Recovery(), serverTimingHeader(), recordMetrics(), limitBodySize(int64(config.MaxBodyMB)<<20))
+	if config.Gzip {
+		r.Use(compressResponses())
+	}
+	r.Use(rateLimit())
+	if config.CORSOrigins != "" {
+		r.Use(allowCORS(config.CORSOrigins, config.CORSMethods, config.CORSHeaders))
+	}
+
+	if config.OTLPEndpoint != "" {
+		shutdown, err := setupTracing(config.OTLPEndpoint)
+		if err != nil {
+			log.Fatalf("tracing: %v", err)
+		}
+		defer shutdown(context.Background())
+		r.Use(traceRequests())
+	}
+
+	// cpu-intensive and matrix requests share one worker pool
+	cpuWorkers := limitCPUWorkers(config.CPUWorkers, config.CPUPoolPolicy)
+	registerWorkloads(r, cpuWorkers, rejectInMaintenance(), timeoutRequests(config.RequestTimeout), requireAuth(), injectFaults(), limitGoroutines(config.MaxGoroutines), verifyBodyChecksum(), limitJSONDepth(config.MaxJSONDepth), disableGC(), cpuTimeGate(), includeSerializationTime())
 
-	// Level 1: Hello World
-	r.GET("/", handleHelloWorld)
 	r.GET("/health", handleHealth)
+	r.GET("/health/live", handleLiveness)
+	r.GET("/health/ready", handleReadiness)
+	r.GET("/config", handleConfig)
+	r.GET("/info", handleInfo)
+	r.GET("/gc", handleGCStats)
+	r.POST("/gc", rejectInMaintenance(), requireAuth(), handleForceGC)
+	if config.Pprof {
+		registerPprof(r)
+	}
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Closed-loop self load generation, replayed against a router
+	// without the middleware above
+	replay := newReplayRouter()
+	r.POST("/selfload", rejectInMaintenance(), timeoutRequests(config.RequestTimeout), requireAuth(), limitGoroutines(config.MaxGoroutines), handleSelfLoad(replay))
+	r.POST("/benchmark", rejectInMaintenance(), timeoutRequests(config.RequestTimeout), requireAuth(), limitGoroutines(config.MaxGoroutines), handleBenchmark(replay))
+	r.POST("/warmup", rejectInMaintenance(), requireAuth(), limitGoroutines(config.MaxGoroutines), handleWarmup(replay))
+	r.POST("/selfbench", rejectInMaintenance(), requireAuth(), limitGoroutines(config.MaxGoroutines), handleSelfBench(replay))
+	r.GET("/stream", rejectInMaintenance(), requireAuth(), limitGoroutines(config.MaxGoroutines), handleStream)
+	r.GET("/sse", rejectInMaintenance(), requireAuth(), limitGoroutines(config.MaxGoroutines), handleSSE)
+	r.GET("/ws", rejectInMaintenance(), requireAuth(), limitGoroutines(config.MaxGoroutines), handleWebSocket)
+
+	if config.Baseline {
+		runBaseline(replay)
+	}
+
+	// Reread mutable settings on SIGHUP
+	go watchReload()
+
+	if err := serve(context.Background(), r); err != nil {
+		log.Fatalf("server: %v", err)
+	}
+}
+
+// registerWorkloads adds the routes that generate load: hello world and
+// the /process group, which runs middleware before every handler.
+// cpuWorkers guards the CPU-bound handlers.
+func registerWorkloads(r *gin.Engine, cpuWorkers gin.HandlerFunc, middleware ...gin.HandlerFunc) {
+	r.GET("/", handleHelloWorld)
+
+	process := r.Group("/process", middleware...)
 
 	// Level 2: Normal Work
-	r.POST("/process/normal", handleNormalWork)
+	process.POST("/normal", handleNormalWork)
+	process.POST("/normal/batch", handleNormalWorkBatch)
 
 	// Level 3: CPU-Intensive Work
-	r.POST("/process/cpu-intensive", handleCPUIntensive)
+	process.POST("/cpu-intensive", cpuWorkers, handleCPUIntensive)
+	process.GET("/cpu-intensive", cpuWorkers, handleCPUIntensive)
 
 	// Level 4: String Processing
-	r.POST("/process/strings", handleStringProcessing)
+	process.POST("/strings", handleStringProcessing)
+
+	// Allocation and GC pressure
+	process.POST("/memory", handleMemory)
+
+	// Goroutine fan-out
+	process.POST("/parallel", handleParallel)
 
-	r.Run(":6002")
+	// Simulated upstream latency
+	process.POST("/io", handleIO)
+
+	// encoding/json round trip
+	process.POST("/json", handleJSONWork)
+
+	// Digest and password hashing
+	process.POST("/hash", handleHash)
+
+	// Floating-point matrix multiplication
+	process.POST("/matrix", cpuWorkers, handleMatrix)
+
+	// Comparison sort
+	process.POST("/sort", handleSort)
+
+	// Time-boxed prime counting
+	process.POST("/primes", handlePrimes)
+}
+
+// How long in-flight requests get to finish once shutdown begins
+const shutdownTimeout = 10 * time.Second
+
+// serve listens on the configured port until SIGINT, SIGTERM or the end of
+// ctx, then stops accepting connections and waits for in-flight requests
+// to complete.
+func serve(ctx context.Context, handler http.Handler) error {
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", config.Port),
+		Handler: handler,
+	}
+
+	logf("info", "server: default workload seed %d", config.Seed)
+	errs := make(chan error, 1)
+	go func() {
+		logf("info", "server: listening on %s", server.Addr)
+		errs <- server.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-signals:
+		logf("info", "server: received %s, shutting down", sig)
+	case <-ctx.Done():
+		logf("info", "server: %v, shutting down", ctx.Err())
+	}
+
+	// Report not ready first, and keep serving for a while if asked so
+	// load balancers notice before the listener closes
+	setNotReady("shutting down")
+	if config.ShutdownDelay > 0 {
+		logf("info", "server: draining for %s", config.ShutdownDelay)
+		time.Sleep(config.ShutdownDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	logf("info", "server: shutdown complete")
+	return nil
 }
 
 func handleHelloWorld(c *gin.Context) {
@@ -57,39 +269,133 @@ func handleHelloWorld(c *gin.Context) {
 }
 
 func handleHealth(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":    "healthy",
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	})
+	status := http.StatusOK
+	result := gin.H{
+		"status":       "healthy",
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+		"auth_enabled": config.AuthToken != "",
+		"goroutines": gin.H{
+			"current": runtime.NumGoroutine(),
+			"limit":   config.MaxGoroutines,
+		},
+	}
+
+	// Report free space where logs and temp files accumulate
+	if total, free, ok := diskUsage("."); ok {
+		minFree := uint64(config.MinFreeDiskMB) << 20
+		result["disk"] = gin.H{
+			"total_bytes":    total,
+			"free_bytes":     free,
+			"min_free_bytes": minFree,
+		}
+		if free < minFree {
+			status = http.StatusServiceUnavailable
+			result["status"] = "degraded"
+		}
+	}
+
+	c.JSON(status, result)
+}
+
+// handleInfo describes the runtime and build of the server under test, so
+// benchmark results can be matched to the environment that produced them.
+func handleInfo(c *gin.Context) {
+	result := gin.H{
+		"go_version": runtime.Version(),
+		"goos":       runtime.GOOS,
+		"goarch":     runtime.GOARCH,
+		"num_cpu":    runtime.NumCPU(),
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+		"goroutines": runtime.NumGoroutine(),
+		"service":    "Go Gin",
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		result["module"] = build.Main.Path
+		result["module_version"] = build.Main.Version
+		deps := gin.H{}
+		for _, dep := range build.Deps {
+			deps[dep.Path] = dep.Version
+		}
+		result["dependencies"] = deps
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				result["vcs_revision"] = setting.Value
+			}
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// handleLiveness reports that the process is up and serving HTTP.
+func handleLiveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// notReadyReason says why /health/ready is failing; it is empty while the
+// server is ready for work.
+var notReadyReason atomic.Value
+
+// setNotReady marks the server not ready for the given reason, or ready
+// again when reason is empty.
+func setNotReady(reason string) {
+	notReadyReason.Store(reason)
+}
+
+// handleReadiness reports whether the server is accepting benchmark work.
+func handleReadiness(c *gin.Context) {
+	reason, _ := notReadyReason.Load().(string)
+	if reason == "" && config.Settings().Maintenance {
+		reason = "maintenance"
+	}
+	if reason == "" {
+		// The same low-disk check that degrades /health
+		if _, free, ok := diskUsage("."); ok && free < uint64(config.MinFreeDiskMB)<<20 {
+			reason = fmt.Sprintf("low disk space: %d MB free, below the minimum of %d MB", free>>20, config.MinFreeDiskMB)
+		}
+	}
+	if reason != "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "reason": reason})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
 }
 
 func handleNormalWork(c *gin.Context) {
 	var req NormalWorkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err, &req))
 		return
 	}
 
-	// Parse birthdate and calculate age
-	parts := strings.Split(req.Birthdate, "-")
-	if len(parts) < 1 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid birthdate format"})
+	result, err := normalWork(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	respond(c, http.StatusOK, result)
+}
 
-	var birthYear int
-	_, err := fmt.Sscanf(parts[0], "%d", &birthYear)
+// normalWork validates one record and derives the normal work endpoint's
+// response fields from it.
+func normalWork(req NormalWorkRequest) (gin.H, error) {
+	// Parse birthdate and calculate age
+	birthdate, err := time.Parse(birthdateLayout, req.Birthdate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid birth year"})
-		return
+		return nil, fmt.Errorf("Invalid birthdate: must be formatted as YYYY-MM-DD")
 	}
 
-	currentYear := time.Now().Year()
-	age := currentYear - birthYear
+	age := ageOn(birthdate, time.Now())
+	if birthdate.Year() < minBirthYear || age < 0 {
+		return nil, fmt.Errorf("Invalid birthdate: must be between %d-01-01 and today", minBirthYear)
+	}
 
-	// Extract username from email
-	emailParts := strings.Split(req.Email, "@")
-	username := emailParts[0]
+	// Extract username from email. The local part may itself be a quoted
+	// string containing "@", so split at the last one.
+	address, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid email: %s", strings.TrimPrefix(err.Error(), "mail: "))
+	}
+	username := address.Address[:strings.LastIndex(address.Address, "@")]
 
 	// Process name
 	nameParts := strings.Fields(req.Name)
@@ -115,15 +421,77 @@ func handleNormalWork(c *gin.Context) {
 	if req.Data != nil {
 		result["extra_data_keys"] = len(req.Data)
 	}
+	return result, nil
+}
+
+// handleNormalWorkBatch runs normalWork over an array of records. Records
+// that fail validation get an error entry in their place rather than
+// failing the whole batch.
+func handleNormalWorkBatch(c *gin.Context) {
+	var reqs []NormalWorkRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&reqs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid batch: " + err.Error()})
+		return
+	}
+	if len(reqs) > config.MaxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Batch too large: must have at most %d items", config.MaxBatchSize)})
+		return
+	}
+
+	startTime := time.Now()
+	cpuTimer := startCPUTimer(c)
+
+	results := make([]gin.H, len(reqs))
+	failed := 0
+	for i, req := range reqs {
+		// Decoding skips the binding tags, so apply them per item
+		err := binding.Validator.ValidateStruct(req)
+		if err == nil {
+			results[i], err = normalWork(req)
+		}
+		if err != nil {
+			results[i] = bindingErrorResponse(err, &req)
+			failed++
+		}
+	}
+
+	endTime := time.Now()
+	setServerTimingTotal(c, endTime.Sub(startTime))
+	result := gin.H{
+		"results":                results,
+		"count":                  len(reqs),
+		"failed":                 failed,
+		"execution_time_seconds": endTime.Sub(startTime).Seconds(),
+		"service":                "Go Gin",
+	}
+	cpuTimer.record(result)
+	reportGCDisabled(c, result)
 
 	c.JSON(http.StatusOK, result)
 }
 
+// ageOn returns the age in whole years on the given day of someone born
+// on birthdate. Until the birthday comes round the year isn't counted, so
+// a 29 February birthday is reached on 1 March in non-leap years.
+func ageOn(birthdate, now time.Time) int {
+	age := now.Year() - birthdate.Year()
+	if now.Month() < birthdate.Month() || (now.Month() == birthdate.Month() && now.Day() < birthdate.Day()) {
+		age--
+	}
+	return age
+}
+
+// fibonacci computes the nth Fibonacci number iteratively, so the cost
+// grows linearly with n rather than exponentially.
 func fibonacci(n int) int {
 	if n <= 1 {
 		return n
 	}
-	return fibonacci(n-1) + fibonacci(n-2)
+	prev, cur := 0, 1
+	for i := 2; i <= n; i++ {
+		prev, cur = cur, prev+cur
+	}
+	return cur
 }
 
 func isPrime(n int) bool {
@@ -146,9 +514,18 @@ func isPrime(n int) bool {
 	return true
 }
 
-func findPrimes(limit int) []int {
+// fibonacciBig is fibonacci for results that overflow an int. Like
+// lucasNaive, it stops early once ctx ends.
+func fibonacciBig(ctx context.Context, n int) *big.Int {
+	return lucasNaive(ctx, n, big.NewInt(0), big.NewInt(1))
+}
+
+func findPrimes(ctx context.Context, limit int) []int {
 	primes := []int{}
 	for i := 2; i <= limit; i++ {
+		if canceled(ctx, i) {
+			break
+		}
 		if isPrime(i) {
 			primes = append(primes, i)
 		}
@@ -156,42 +533,215 @@ func findPrimes(limit int) []int {
 	return primes
 }
 
-func handleCPUIntensive(c *gin.Context) {
+// eratosthenesPrimes returns the primes up to limit using the Sieve of
+// Eratosthenes, crossing off multiples of each prime from its square.
+func eratosthenesPrimes(ctx context.Context, limit int) []int {
+	composite := make([]bool, limit+1)
+	primes := []int{}
+	for n := 2; n <= limit; n++ {
+		if canceled(ctx, n) {
+			break
+		}
+		if composite[n] {
+			continue
+		}
+		primes = append(primes, n)
+		for i := n * n; i <= limit; i += n {
+			composite[i] = true
+		}
+	}
+	return primes
+}
+
+// atkinPrimes returns the primes up to limit using the Sieve of Atkin.
+func atkinPrimes(ctx context.Context, limit int) []int {
+	sieve := make([]bool, limit+1)
+	for x := 1; x*x <= limit; x++ {
+		// Each x covers sqrt(limit) values of y
+		if ctx.Err() != nil {
+			return nil
+		}
+		for y := 1; y*y <= limit; y++ {
+			n := 4*x*x + y*y
+			if n <= limit && (n%12 == 1 || n%12 == 5) {
+				sieve[n] = !sieve[n]
+			}
+			n = 3*x*x + y*y
+			if n <= limit && n%12 == 7 {
+				sieve[n] = !sieve[n]
+			}
+			n = 3*x*x - y*y
+			if x > y && n <= limit && n%12 == 11 {
+				sieve[n] = !sieve[n]
+			}
+		}
+	}
+
+	// Eliminate multiples of squares of primes
+	for r := 5; r*r <= limit; r++ {
+		if sieve[r] {
+			for i := r * r; i <= limit; i += r * r {
+				sieve[i] = false
+			}
+		}
+	}
+
+	primes := []int{}
+	if limit >= 2 {
+		primes = append(primes, 2)
+	}
+	if limit >= 3 {
+		primes = append(primes, 3)
+	}
+	for n := 5; n <= limit; n++ {
+		if canceled(ctx, n) {
+			break
+		}
+		if sieve[n] {
+			primes = append(primes, n)
+		}
+	}
+	return primes
+}
+
+// bindCPUIntensiveRequest reads the request from the JSON body, if any,
+// then applies n, prime_limit and int_only from the query string, so that
+// GET /process/cpu-intensive?n=35 works for URL-only load generators.
+func bindCPUIntensiveRequest(c *gin.Context) (CPUIntensiveRequest, error) {
 	var req CPUIntensiveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		req.N = 35 // Default value
+		// A body that decoded but failed validation is checked again below,
+		// once the query has had its say
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			req.N = 35 // Default value
+		}
+	}
+
+	query := c.Request.URL.Query()
+	var err error
+	if req.N, err = queryInt(query, "n", req.N); err != nil {
+		return req, err
+	}
+	if req.PrimeLimit, err = queryInt(query, "prime_limit", req.PrimeLimit); err != nil {
+		return req, err
+	}
+	if raw := query.Get("int_only"); raw != "" {
+		if req.IntOnly, err = strconv.ParseBool(raw); err != nil {
+			return req, fmt.Errorf("Invalid int_only: must be true or false")
+		}
+	}
+	return req, binding.Validator.ValidateStruct(&req)
+}
+
+func handleCPUIntensive(c *gin.Context) {
+	// Alternative workloads are selected with ?func=
+	if fn := c.Query("func"); fn != "" {
+		handleCPUFunc(c, fn)
+		return
+	}
+
+	req, err := bindCPUIntensiveRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err, &req))
+		return
+	}
+
+	// The prime phase's limit comes from prime_limit, or else ?limit=, and
+	// its algorithm can be chosen with ?algo=
+	primeLimit := req.PrimeLimit
+	if primeLimit == 0 {
+		var err error
+		primeLimit, err = queryInt(c.Request.URL.Query(), "limit", defaultPrimeLimit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if primeLimit < 2 || primeLimit > config.MaxPrimeLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid prime_limit: must be between 2 and %d", config.MaxPrimeLimit)})
+		return
+	}
+	if req.N > config.MaxN {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid n: must not exceed %d", config.MaxN)})
+		return
+	}
+	overflows := req.N > maxIntFibonacciN
+	if overflows && req.IntOnly {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("fibonacci(%d) overflows int; n must not exceed %d when int_only is set", req.N, maxIntFibonacciN),
+		})
+		return
+	}
+	algo := c.DefaultQuery("algo", "sieve")
+	findPrimesWith, ok := primeAlgorithms[algo]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown algo: " + algo})
+		return
 	}
 
 	startTime := time.Now()
+	cpuTimer := startCPUTimer(c)
 
 	// Calculate Fibonacci
-	fibResult := fibonacci(req.N)
+	_, span := tracer.Start(c.Request.Context(), "fibonacci")
+	var fibResult int
+	var fibBig *big.Int
+	if overflows {
+		fibBig = fibonacciBig(c.Request.Context(), req.N)
+	} else {
+		fibResult = fibonacci(req.N)
+	}
+	span.End()
+	fibTime := time.Now()
+	addServerTiming(c, "fib", fibTime.Sub(startTime))
+	if timedOut(c) {
+		return
+	}
 
 	// Find primes
-	primes := findPrimes(10000)
+	_, span = tracer.Start(c.Request.Context(), "primes")
+	primes := findPrimesWith(c.Request.Context(), primeLimit)
+	span.End()
+	addServerTiming(c, "primes", time.Since(fibTime))
+	if timedOut(c) {
+		return
+	}
 
 	endTime := time.Now()
-	executionTime := endTime.Sub(startTime).Seconds()
+	executionTime := endTime.Sub(startTime)
+	setServerTimingTotal(c, executionTime)
 
 	largestPrime := 0
 	if len(primes) > 0 {
 		largestPrime = primes[len(primes)-1]
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"fibonacci_n":             req.N,
-		"fibonacci_result":        fibResult,
-		"primes_count":            len(primes),
-		"largest_prime":           largestPrime,
-		"execution_time_seconds":  executionTime,
-		"service":                 "Go Gin",
-	})
+	result := gin.H{
+		"fibonacci_n":            req.N,
+		"prime_algorithm":        algo,
+		"prime_limit":            primeLimit,
+		"primes_count":           len(primes),
+		"largest_prime":          largestPrime,
+		"execution_time_seconds": executionTime.Seconds(),
+		"execution_time_ns":      executionTime.Nanoseconds(),
+		"service":                "Go Gin",
+	}
+	if overflows {
+		result["fibonacci_result_big"] = fibBig.String()
+	} else {
+		result["fibonacci_result"] = fibResult
+	}
+	cpuTimer.record(result)
+	reportGCDisabled(c, result)
+
+	respond(c, http.StatusOK, result)
 }
 
 func handleStringProcessing(c *gin.Context) {
 	var req StringProcessRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err, &req))
 		return
 	}
 
@@ -200,6 +750,7 @@ func handleStringProcessing(c *gin.Context) {
 	}
 
 	startTime := time.Now()
+	cpuTimer := startCPUTimer(c)
 	textLength := len(req.Text)
 
 	result := gin.H{
@@ -215,23 +766,14 @@ func handleStringProcessing(c *gin.Context) {
 		}
 		processed := string(runes)
 		result["processed_length"] = len(processed)
-		if len(processed) > 100 {
-			result["sample"] = processed[:100]
-		} else {
-			result["sample"] = processed
-		}
+		result["sample"] = sample(processed)
 
 	case "uppercase":
 		processed := strings.ToUpper(req.Text)
 		result["processed_length"] = len(processed)
-		if len(processed) > 100 {
-			result["sample"] = processed[:100]
-		} else {
-			result["sample"] = processed
-		}
+		result["sample"] = sample(processed)
 
 	case "count":
-		lines := strings.Split(req.Text, "\n")
 		words := strings.Fields(req.Text)
 		uniqueChars := make(map[rune]bool)
 		for _, ch := range req.Text {
@@ -240,7 +782,7 @@ func handleStringProcessing(c *gin.Context) {
 
 		result["char_count"] = len(req.Text)
 		result["word_count"] = len(words)
-		result["line_count"] = len(lines)
+		result["line_count"] = countLines(req.Text)
 		result["unique_chars"] = len(uniqueChars)
 
 	case "pattern":
@@ -255,19 +797,20 @@ func handleStringProcessing(c *gin.Context) {
 			Word  string `json:"word"`
 			Count int    `json:"count"`
 		}
-		var topWords []wordCount
+		// Never nil, so an input without words gives [] rather than null
+		topWords := make([]wordCount, 0, len(wordFreq))
 		for word, count := range wordFreq {
 			topWords = append(topWords, wordCount{Word: word, Count: count})
 		}
 
-		// Sort by count (simple bubble sort for top 10)
-		for i := 0; i < len(topWords) && i < 10; i++ {
-			for j := i + 1; j < len(topWords); j++ {
-				if topWords[j].Count > topWords[i].Count {
-					topWords[i], topWords[j] = topWords[j], topWords[i]
-				}
+		// Most frequent first; ties are broken alphabetically so the
+		// result doesn't depend on map iteration order
+		sort.SliceStable(topWords, func(i, j int) bool {
+			if topWords[i].Count != topWords[j].Count {
+				return topWords[i].Count > topWords[j].Count
 			}
-		}
+			return topWords[i].Word < topWords[j].Word
+		})
 
 		if len(topWords) > 10 {
 			topWords = topWords[:10]
@@ -277,26 +820,166 @@ func handleStringProcessing(c *gin.Context) {
 		result["unique_words"] = len(wordFreq)
 
 	case "concatenate":
-		iterations := 10
-		if textLength > 0 {
-			iterations = min(10, 1000000/textLength)
+		iterations := req.Iterations
+		if iterations == 0 {
+			iterations = max(1, min(10, 1000000/max(textLength, 1)))
+		}
+		if maxIterations := maxConcatenateBytes / max(textLength, 1); iterations < 1 || iterations > maxIterations {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid iterations: must be between 1 and %d for this text", maxIterations)})
+			return
 		}
 		processed := strings.Repeat(req.Text, iterations)
 		result["iterations"] = iterations
 		result["final_length"] = len(processed)
 
+	case "luhn":
+		if err := luhnOperation(req.Text, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "csv_stats":
+		if err := csvStatsOperation(req.Text, req.RaggedTolerance, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "wordbreak":
+		if err := wordBreakOperation(req.Text, req.Dictionary, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "jaccard":
+		if err := jaccardOperation(req.Text, req.Text2, req.ShingleSize, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "script_detect":
+		scriptDetectOperation(req.Text, result)
+
+	case "multisort":
+		multiSortOperation(req.Text, result)
+
+	case "spellcheck":
+		if err := spellcheckOperation(req.Text, req.MaxDistance, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "readability":
+		if err := readabilityOperation(req.Text, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "fuzzy_match":
+		if err := fuzzyMatchOperation(req.Text, req.Pattern, req.MaxDistance, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "runs":
+		runsOperation(req.Text, result)
+
+	case "balance":
+		balanceOperation(req.Text, result)
+
+	case "simhash":
+		if err := simHashOperation(req.Text, req.Text2, req.ShingleSize, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "tfidf":
+		tfidfOperation(req.Text, req.Delimiter, result)
+
+	case "titlecase":
+		titlecaseOperation(req.Text, result)
+
+	case "rot13":
+		rot13Operation(req.Text, result)
+
+	case "palindrome_check":
+		palindromeOperation(req.Text, result)
+
+	case "checksum":
+		if err := checksumOperation(req.Text, req.Variant, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "base64":
+		base64Operation(req.Text, result)
+
+	case "regex":
+		if err := regexOperation(req.Text, req.Pattern, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "boyermoore":
+		if err := boyerMooreOperation(req.Text, req.Pattern, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case "suffix_automaton":
+		if err := suffixAutomatonOperation(req.Text, result); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown operation: " + req.Operation})
 		return
 	}
 
+	if timedOut(c) {
+		return
+	}
+
 	endTime := time.Now()
+	setServerTimingTotal(c, endTime.Sub(startTime))
 	result["execution_time_seconds"] = endTime.Sub(startTime).Seconds()
+	result["execution_time_ns"] = endTime.Sub(startTime).Nanoseconds()
+	cpuTimer.record(result)
+	reportGCDisabled(c, result)
 	result["service"] = "Go Gin"
 
 	c.JSON(http.StatusOK, result)
 }
 
+// Characters of processed text echoed back in a response's sample field
+const sampleRunes = 100
+
+// Largest string the concatenate operation will build
+const maxConcatenateBytes = 64 << 20
+
+// countLines counts newline-terminated lines plus a final unterminated one,
+// so "" has 0 lines and "a\n" has 1.
+func countLines(s string) int {
+	n := strings.Count(s, "\n")
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		n++
+	}
+	return n
+}
+
+// sample returns the first sampleRunes runes of s. It cuts on a rune
+// boundary so the sample stays valid UTF-8.
+func sample(s string) string {
+	count := 0
+	for i := range s {
+		if count == sampleRunes {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
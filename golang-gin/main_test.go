@@ -0,0 +1,862 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/bits"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	// The testing flags are already registered, so the server's own flags
+	// parse alongside them and tests see the production defaults
+	if err := loadConfig(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// doRequest sends a request through r in-process and returns the recorded
+// response. A non-empty body is sent as JSON.
+func doRequest(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decodeJSON decodes a JSON object response body.
+func decodeJSON(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body, err)
+	}
+	return resp
+}
+
+// setConfig sets a config field for the rest of the test.
+func setConfig[T any](t *testing.T, field *T, value T) {
+	old := *field
+	*field = value
+	t.Cleanup(func() { *field = old })
+}
+
+func TestNormalWorkBirthdate(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/normal", handleNormalWork)
+
+	nextYear := time.Now().Year() + 1
+	tests := []struct {
+		name      string
+		birthdate string
+		status    int
+		error     string
+	}{
+		{"valid", "1990-05-15", http.StatusOK, ""},
+		{"trailing garbage", "1990abc", http.StatusBadRequest, "Invalid birthdate: must be formatted as YYYY-MM-DD"},
+		{"trailing garbage in year", "1990abc-05-15", http.StatusBadRequest, "Invalid birthdate: must be formatted as YYYY-MM-DD"},
+		{"future year", fmt.Sprintf("%d-01-01", nextYear), http.StatusBadRequest, "Invalid birthdate: must be between 1900-01-01 and today"},
+		{"zero-padded year", "0990-05-15", http.StatusBadRequest, "Invalid birthdate: must be between 1900-01-01 and today"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"name":"Ada Lovelace","birthdate":%q,"email":"ada@example.com"}`, tt.birthdate)
+			w := doRequest(r, http.MethodPost, "/process/normal", body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			var resp map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if tt.error != "" && resp["error"] != tt.error {
+				t.Errorf("error = %v, want %q", resp["error"], tt.error)
+			}
+		})
+	}
+}
+
+func TestAtkinPrimesMatchOtherAlgorithms(t *testing.T) {
+	for _, limit := range []int{0, 1, 2, 3, 4, 5, 6, 7, 10, 11, 12, 13, 25, 29, 30, 100, 1000, 10007, 100000} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			atkin := atkinPrimes(context.Background(), limit)
+			if want := eratosthenesPrimes(context.Background(), limit); !reflect.DeepEqual(atkin, want) {
+				t.Errorf("atkin found %d primes, eratosthenes %d", len(atkin), len(want))
+			}
+			if want := findPrimes(context.Background(), limit); !reflect.DeepEqual(atkin, want) {
+				t.Errorf("atkin found %d primes, trial division %d", len(atkin), len(want))
+			}
+		})
+	}
+}
+
+func TestAgeOn(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse(birthdateLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		name      string
+		birthdate string
+		now       string
+		age       int
+	}{
+		{"birthday today", "1990-06-15", "2020-06-15", 30},
+		{"day before birthday", "1990-06-15", "2020-06-14", 29},
+		{"earlier month", "1990-06-15", "2020-05-30", 29},
+		{"later month", "1990-06-15", "2020-07-01", 30},
+		{"leap day in a leap year", "2000-02-29", "2024-02-29", 24},
+		{"leap day, 28 February of a common year", "2000-02-29", "2023-02-28", 22},
+		{"leap day, 1 March of a common year", "2000-02-29", "2023-03-01", 23},
+		{"born today", "2020-06-15", "2020-06-15", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if age := ageOn(date(tt.birthdate), date(tt.now)); age != tt.age {
+				t.Errorf("ageOn(%s, %s) = %d, want %d", tt.birthdate, tt.now, age, tt.age)
+			}
+		})
+	}
+}
+
+func TestNormalWorkAgeOnBirthday(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/normal", handleNormalWork)
+
+	// 20 years keeps a 29 February birthday on a leap day
+	birthdate := time.Now().AddDate(-20, 0, 0).Format(birthdateLayout)
+	body := fmt.Sprintf(`{"name":"Ada Lovelace","birthdate":%q,"email":"ada@example.com"}`, birthdate)
+	w := doRequest(r, http.MethodPost, "/process/normal", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Age     int  `json:"age"`
+		IsAdult bool `json:"is_adult"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Age != 20 || !resp.IsAdult {
+		t.Errorf("age = %d, is_adult = %v, want 20 and true", resp.Age, resp.IsAdult)
+	}
+}
+
+// fibonacciRecursive is the exponential-time fibonacci the handler used
+// before, kept as a reference for the benchmark.
+func fibonacciRecursive(n int) int {
+	if n <= 1 {
+		return n
+	}
+	return fibonacciRecursive(n-1) + fibonacciRecursive(n-2)
+}
+
+func TestFibonacciMatchesRecursive(t *testing.T) {
+	for n := 0; n <= 30; n++ {
+		if got, want := fibonacci(n), fibonacciRecursive(n); got != want {
+			t.Errorf("fibonacci(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+	const n = 35
+	b.Run("recursive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			fibonacciRecursive(n)
+		}
+	})
+	b.Run("iterative", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			fibonacci(n)
+		}
+	})
+}
+
+func TestCPUIntensiveRejectsNegativeN(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/cpu-intensive", handleCPUIntensive)
+	r.GET("/process/cpu-intensive", handleCPUIntensive)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"post", http.MethodPost, "/process/cpu-intensive", `{"n":-1}`},
+		{"get", http.MethodGet, "/process/cpu-intensive?n=-1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, tt.method, tt.path, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+		})
+	}
+}
+
+func TestCPUIntensiveFibonacciOverflowBoundary(t *testing.T) {
+	if bits.UintSize != 64 {
+		t.Skip("the boundary is at N=92 on 64-bit platforms")
+	}
+	r := gin.New()
+	r.POST("/process/cpu-intensive", handleCPUIntensive)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		field  string
+		value  string
+	}{
+		{"largest int result", `{"n":92}`, http.StatusOK, "fibonacci_result", "7540113804746346429"},
+		{"first big result", `{"n":93}`, http.StatusOK, "fibonacci_result_big", "12200160415121876738"},
+		{"int_only at the boundary", `{"n":92,"int_only":true}`, http.StatusOK, "fibonacci_result", "7540113804746346429"},
+		{"int_only above the boundary", `{"n":93,"int_only":true}`, http.StatusBadRequest, "error", "fibonacci(93) overflows int; n must not exceed 92 when int_only is set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/cpu-intensive", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			var resp map[string]interface{}
+			decoder := json.NewDecoder(w.Body)
+			decoder.UseNumber()
+			if err := decoder.Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(resp[tt.field]); got != tt.value {
+				t.Errorf("%s = %s, want %s", tt.field, got, tt.value)
+			}
+		})
+	}
+}
+
+func TestEratosthenesMatchesTrialDivision(t *testing.T) {
+	sieve, trial := eratosthenesPrimes(context.Background(), 10000), findPrimes(context.Background(), 10000)
+	if !reflect.DeepEqual(sieve, trial) {
+		t.Fatalf("sieve found %d primes, trial division %d", len(sieve), len(trial))
+	}
+	if len(sieve) != 1229 {
+		t.Errorf("found %d primes up to 10000, want 1229", len(sieve))
+	}
+}
+
+func TestCPUIntensivePrimeLimitCap(t *testing.T) {
+	setConfig(t, &config.MaxPrimeLimit, 50000)
+	r := gin.New()
+	r.POST("/process/cpu-intensive", handleCPUIntensive)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"default", `{"n":10}`, http.StatusOK},
+		{"at the cap", `{"n":10,"prime_limit":50000}`, http.StatusOK},
+		{"above the cap", `{"n":10,"prime_limit":50001}`, http.StatusBadRequest},
+		{"below 2", `{"n":10,"prime_limit":1}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/cpu-intensive", tt.body)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestSampleKeepsValidUTF8(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		runes int
+	}{
+		{"ascii", strings.Repeat("a", 150), sampleRunes},
+		{"cjk", strings.Repeat("漢字かな", 40), sampleRunes},
+		{"emoji", strings.Repeat("🚀👍🏽", 60), sampleRunes},
+		{"short", "héllo 🌍", 7},
+		{"empty", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sample(tt.text)
+			if !utf8.ValidString(s) {
+				t.Errorf("sample %q is not valid UTF-8", s)
+			}
+			if n := utf8.RuneCountInString(s); n != tt.runes {
+				t.Errorf("sample has %d runes, want %d", n, tt.runes)
+			}
+			if !strings.HasPrefix(tt.text, s) {
+				t.Errorf("sample %q is not a prefix of the text", s)
+			}
+		})
+	}
+}
+
+func TestStringProcessingSampleIsValidUTF8(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/strings", handleStringProcessing)
+
+	text := strings.Repeat("日本語のテキスト😀", 30)
+	for _, operation := range []string{"reverse", "uppercase"} {
+		t.Run(operation, func(t *testing.T) {
+			body := fmt.Sprintf(`{"text":%q,"operation":%q}`, text, operation)
+			w := doRequest(r, http.MethodPost, "/process/strings", body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var resp struct {
+				Sample string `json:"sample"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			// Invalid UTF-8 would have been replaced with U+FFFD when encoding
+			if !utf8.ValidString(resp.Sample) || strings.ContainsRune(resp.Sample, utf8.RuneError) {
+				t.Errorf("sample %q is not valid UTF-8", resp.Sample)
+			}
+			if n := utf8.RuneCountInString(resp.Sample); n != sampleRunes {
+				t.Errorf("sample has %d runes, want %d", n, sampleRunes)
+			}
+		})
+	}
+}
+
+// freePort returns a TCP port that was free a moment ago.
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// testClient opens a fresh connection per request, so no idle keep-alive
+// connection is left to hold up a server's shutdown.
+var testClient = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+// testServer is a serve call running in the background.
+type testServer struct {
+	stop context.CancelFunc
+	done <-chan error
+}
+
+// startServer runs serve on config.Port in the background and waits until
+// it answers.
+func startServer(t *testing.T, handler http.Handler) *testServer {
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serve(ctx, handler) }()
+	s := &testServer{stop: stop, done: done}
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/", config.Port)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err := testClient.Get(url); err == nil {
+			resp.Body.Close()
+			return s
+		}
+	}
+	stopServer(t, s)
+	t.Fatalf("server did not start on port %d", config.Port)
+	return nil
+}
+
+// stopServer cancels serve's context and waits for it to return, allowing
+// for the full shutdownTimeout.
+func stopServer(t *testing.T, s *testServer) {
+	s.stop()
+	select {
+	case err := <-s.done:
+		if err != nil {
+			t.Fatalf("serve: %v", err)
+		}
+	case <-time.After(shutdownTimeout + 5*time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
+
+func TestServeShutsDownGracefully(t *testing.T) {
+	setConfig(t, &config.Port, freePort(t))
+	t.Cleanup(func() { setNotReady("") })
+
+	r := gin.New()
+	r.GET("/", handleHelloWorld)
+	started := make(chan struct{})
+	r.GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+	server := startServer(t, r)
+	base := fmt.Sprintf("http://127.0.0.1:%d", config.Port)
+
+	// A request in flight when shutdown begins still completes
+	slow := make(chan error, 1)
+	go func() {
+		resp, err := testClient.Get(base + "/slow")
+		if err == nil {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK || string(body) != "done" {
+				err = fmt.Errorf("status %d, body %q", resp.StatusCode, body)
+			}
+		}
+		slow <- err
+	}()
+	<-started
+
+	stopServer(t, server)
+	if err := <-slow; err != nil {
+		t.Errorf("in-flight request: %v", err)
+	}
+
+	// And no new connections are accepted afterwards
+	if conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", config.Port), time.Second); err == nil {
+		conn.Close()
+		t.Error("server still accepts connections after shutdown")
+	}
+}
+
+func TestStringProcessingConcatenate(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/strings", handleStringProcessing)
+
+	tests := []struct {
+		name       string
+		textLength int
+		iterations int
+		status     int
+		want       int
+	}{
+		{"short text", 1000, 0, http.StatusOK, 10},
+		{"150KB text", 150000, 0, http.StatusOK, 6},
+		{"200KB text", 200000, 0, http.StatusOK, 5},
+		{"text over 1MB", 1500000, 0, http.StatusOK, 1},
+		{"explicit iterations", 200000, 3, http.StatusOK, 3},
+		{"too many iterations", 200000, maxConcatenateBytes/200000 + 1, http.StatusBadRequest, 0},
+		{"negative iterations", 1000, -1, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"text":%q,"operation":"concatenate","iterations":%d}`, strings.Repeat("a", tt.textLength), tt.iterations)
+			w := doRequest(r, http.MethodPost, "/process/strings", body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			resp := decodeJSON(t, w)
+			if resp["iterations"] != float64(tt.want) {
+				t.Errorf("iterations = %v, want %d", resp["iterations"], tt.want)
+			}
+			if resp["final_length"] != float64(tt.want*tt.textLength) {
+				t.Errorf("final_length = %v, want %d", resp["final_length"], tt.want*tt.textLength)
+			}
+		})
+	}
+}
+
+func TestStringProcessingPatternTopWords(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/strings", handleStringProcessing)
+
+	type wordCount struct {
+		Word  string `json:"word"`
+		Count int    `json:"count"`
+	}
+	tests := []struct {
+		name string
+		text string
+		want []wordCount
+	}{
+		{
+			"tie broken alphabetically",
+			"the cat and the dog and The bird",
+			[]wordCount{{"the", 3}, {"and", 2}, {"bird", 1}, {"cat", 1}, {"dog", 1}},
+		},
+		{
+			"only the top ten",
+			"k j i h g f e d c b a a",
+			[]wordCount{{"a", 2}, {"b", 1}, {"c", 1}, {"d", 1}, {"e", 1}, {"f", 1}, {"g", 1}, {"h", 1}, {"i", 1}, {"j", 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"text":%q,"operation":"pattern"}`, tt.text)
+			w := doRequest(r, http.MethodPost, "/process/strings", body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var resp struct {
+				TopWords []wordCount `json:"top_words"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(resp.TopWords, tt.want) {
+				t.Errorf("top_words = %v, want %v", resp.TopWords, tt.want)
+			}
+		})
+	}
+}
+
+func TestInfo(t *testing.T) {
+	r := gin.New()
+	r.GET("/info", handleInfo)
+
+	w := doRequest(r, http.MethodGet, "/info", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	resp := decodeJSON(t, w)
+	for _, key := range []string{"go_version", "goos", "goarch", "num_cpu", "gomaxprocs", "goroutines", "service"} {
+		if _, ok := resp[key]; !ok {
+			t.Errorf("response has no %s", key)
+		}
+	}
+	if n, _ := resp["num_cpu"].(float64); n <= 0 {
+		t.Errorf("num_cpu = %v, want a positive number", resp["num_cpu"])
+	}
+	if resp["go_version"] != runtime.Version() {
+		t.Errorf("go_version = %v, want %s", resp["go_version"], runtime.Version())
+	}
+}
+
+func TestCPUIntensiveGETAndPOST(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/cpu-intensive", handleCPUIntensive)
+	r.GET("/process/cpu-intensive", handleCPUIntensive)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		status     int
+		n          float64
+		primeLimit float64
+	}{
+		{"post", http.MethodPost, "/process/cpu-intensive", `{"n":20,"prime_limit":500}`, http.StatusOK, 20, 500},
+		{"post without a body", http.MethodPost, "/process/cpu-intensive", "", http.StatusOK, 35, defaultPrimeLimit},
+		{"get", http.MethodGet, "/process/cpu-intensive?n=20&prime_limit=500", "", http.StatusOK, 20, 500},
+		{"get with defaults", http.MethodGet, "/process/cpu-intensive", "", http.StatusOK, 35, defaultPrimeLimit},
+		{"query overrides body", http.MethodPost, "/process/cpu-intensive?n=12", `{"n":20,"prime_limit":500}`, http.StatusOK, 12, 500},
+		{"get with limit alias", http.MethodGet, "/process/cpu-intensive?n=5&limit=300", "", http.StatusOK, 5, 300},
+		{"get with bad n", http.MethodGet, "/process/cpu-intensive?n=abc", "", http.StatusBadRequest, 0, 0},
+		{"get with bad int_only", http.MethodGet, "/process/cpu-intensive?int_only=maybe", "", http.StatusBadRequest, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, tt.method, tt.path, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			resp := decodeJSON(t, w)
+			if resp["fibonacci_n"] != tt.n || resp["prime_limit"] != tt.primeLimit {
+				t.Errorf("fibonacci_n = %v, prime_limit = %v, want %v and %v", resp["fibonacci_n"], resp["prime_limit"], tt.n, tt.primeLimit)
+			}
+		})
+	}
+}
+
+func TestCPUIntensiveCaps(t *testing.T) {
+	setConfig(t, &config.MaxN, 1000)
+	setConfig(t, &config.MaxPrimeLimit, 20000)
+	r := gin.New()
+	r.POST("/process/cpu-intensive", handleCPUIntensive)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"n at the cap", `{"n":1000}`, http.StatusOK},
+		{"n above the cap", `{"n":1001}`, http.StatusBadRequest},
+		{"prime_limit at the cap", `{"prime_limit":20000}`, http.StatusOK},
+		{"prime_limit above the cap", `{"prime_limit":20001}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/cpu-intensive", tt.body)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+
+	// Below the caps the defaults are unchanged
+	w := doRequest(r, http.MethodPost, "/process/cpu-intensive", "")
+	resp := decodeJSON(t, w)
+	if resp["fibonacci_n"] != 35.0 || resp["prime_limit"] != 10000.0 || resp["fibonacci_result"] != 9227465.0 {
+		t.Errorf("defaults gave fibonacci_n = %v, prime_limit = %v, fibonacci_result = %v", resp["fibonacci_n"], resp["prime_limit"], resp["fibonacci_result"])
+	}
+}
+
+func TestNormalWorkEmail(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/normal", handleNormalWork)
+
+	tests := []struct {
+		name     string
+		email    string
+		status   int
+		username string
+	}{
+		{"plain address", "ada@example.com", http.StatusOK, "ada"},
+		{"display name", "Ada Lovelace <ada@example.com>", http.StatusOK, "ada"},
+		{"quoted local part with @", `"ada@home"@example.com`, http.StatusOK, "ada@home"},
+		{"no @", "ada.example.com", http.StatusBadRequest, ""},
+		{"malformed", "ada@@example..com", http.StatusBadRequest, ""},
+		{"empty local part", "@example.com", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"name":"Ada Lovelace","birthdate":"1990-05-15","email":%q}`, tt.email)
+			w := doRequest(r, http.MethodPost, "/process/normal", body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			resp := decodeJSON(t, w)
+			if tt.status != http.StatusOK {
+				if msg, _ := resp["error"].(string); !strings.HasPrefix(msg, "Invalid email: ") {
+					t.Errorf("error = %q, want an invalid email message", msg)
+				}
+				return
+			}
+			if resp["username"] != tt.username {
+				t.Errorf("username = %v, want %q", resp["username"], tt.username)
+			}
+		})
+	}
+}
+
+func TestNormalWorkBatch(t *testing.T) {
+	setConfig(t, &config.MaxBatchSize, 3)
+	r := gin.New()
+	r.POST("/process/normal/batch", handleNormalWorkBatch)
+
+	valid := `{"name":"Ada Lovelace","birthdate":"1990-05-15","email":"ada@example.com"}`
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		failed int
+		errors []string
+	}{
+		{"all valid", "[" + valid + "," + valid + "]", http.StatusOK, 0, []string{"", ""}},
+		{
+			"mixed",
+			"[" + valid + `,{"name":"Bob","birthdate":"1990abc","email":"bob@example.com"},{"birthdate":"1990-05-15","email":"c@example.com"}]`,
+			http.StatusOK, 2,
+			[]string{"", "Invalid birthdate: must be formatted as YYYY-MM-DD", "Invalid request body"},
+		},
+		{"empty", "[]", http.StatusOK, 0, []string{}},
+		{"at the cap", "[" + valid + "," + valid + "," + valid + "]", http.StatusOK, 0, []string{"", "", ""}},
+		{"above the cap", "[" + valid + "," + valid + "," + valid + "," + valid + "]", http.StatusBadRequest, 0, nil},
+		{"not an array", valid, http.StatusBadRequest, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/normal/batch", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Results []map[string]interface{} `json:"results"`
+				Count   int                      `json:"count"`
+				Failed  int                      `json:"failed"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Count != len(tt.errors) || len(resp.Results) != len(tt.errors) || resp.Failed != tt.failed {
+				t.Fatalf("count = %d with %d results and %d failed, want %d and %d failed", resp.Count, len(resp.Results), resp.Failed, len(tt.errors), tt.failed)
+			}
+			for i, want := range tt.errors {
+				if got, _ := resp.Results[i]["error"].(string); got != want {
+					t.Errorf("results[%d] error = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+
+	// An item failing its binding tags reports the field, as /process/normal does
+	w := doRequest(r, http.MethodPost, "/process/normal/batch", `[{"birthdate":"1990-05-15","email":"c@example.com"}]`)
+	var resp struct {
+		Results []struct {
+			Fields map[string]string `json:"fields"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Fields["name"] == "" {
+		t.Errorf("results = %s, want a field error for name", w.Body)
+	}
+}
+
+func TestHealthDisk(t *testing.T) {
+	if _, _, ok := diskUsage("."); !ok {
+		t.Skip("disk usage is not available on " + runtime.GOOS)
+	}
+	if _, _, ok := diskUsage("/does/not/exist"); ok {
+		t.Error("diskUsage of a missing path reported ok")
+	}
+
+	r := gin.New()
+	r.GET("/health", handleHealth)
+
+	tests := []struct {
+		name      string
+		minFreeMB int
+		status    int
+		health    string
+	}{
+		{"enough space", 0, http.StatusOK, "healthy"},
+		{"below the minimum", math.MaxInt32, http.StatusServiceUnavailable, "degraded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, &config.MinFreeDiskMB, tt.minFreeMB)
+			w := doRequest(r, http.MethodGet, "/health", "")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			resp := decodeJSON(t, w)
+			if resp["status"] != tt.health {
+				t.Errorf("status field = %v, want %s", resp["status"], tt.health)
+			}
+			disk, _ := resp["disk"].(map[string]interface{})
+			total, _ := disk["total_bytes"].(float64)
+			free, _ := disk["free_bytes"].(float64)
+			if total <= 0 || free > total || disk["min_free_bytes"] != float64(uint64(tt.minFreeMB)<<20) {
+				t.Errorf("disk = %v", resp["disk"])
+			}
+		})
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	setConfig(t, &config.MinFreeDiskMB, 0)
+	t.Cleanup(func() { setNotReady("") })
+	setMaintenance := func(on bool) {
+		config.mu.Lock()
+		config.settings.Maintenance = on
+		config.mu.Unlock()
+	}
+	t.Cleanup(func() { setMaintenance(false) })
+
+	r := gin.New()
+	r.GET("/health/live", handleLiveness)
+	r.GET("/health/ready", handleReadiness)
+
+	type readinessStep struct {
+		name   string
+		change func()
+		status int
+		reason string
+	}
+	steps := []readinessStep{
+		{"ready at first", func() {}, http.StatusOK, ""},
+		{"shutting down", func() { setNotReady("shutting down") }, http.StatusServiceUnavailable, "shutting down"},
+		{"ready again", func() { setNotReady("") }, http.StatusOK, ""},
+		{"maintenance", func() { setMaintenance(true) }, http.StatusServiceUnavailable, "maintenance"},
+		{"out of maintenance", func() { setMaintenance(false) }, http.StatusOK, ""},
+	}
+	if _, _, ok := diskUsage("."); ok {
+		steps = append(steps, readinessStep{"low disk", func() { setConfig(t, &config.MinFreeDiskMB, math.MaxInt32) }, http.StatusServiceUnavailable, "low disk space"})
+	}
+	for _, step := range steps {
+		step.change()
+		w := doRequest(r, http.MethodGet, "/health/ready", "")
+		if w.Code != step.status {
+			t.Fatalf("%s: status = %d, want %d: %s", step.name, w.Code, step.status, w.Body)
+		}
+		if reason, _ := decodeJSON(t, w)["reason"].(string); !strings.HasPrefix(reason, step.reason) {
+			t.Errorf("%s: reason = %q, want %q", step.name, reason, step.reason)
+		}
+		// Liveness doesn't follow readiness
+		if w := doRequest(r, http.MethodGet, "/health/live", ""); w.Code != http.StatusOK {
+			t.Errorf("%s: liveness status = %d, want %d", step.name, w.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestStringProcessingBlankText(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/strings", handleStringProcessing)
+
+	tests := []struct {
+		name        string
+		text        string
+		chars       float64
+		words       float64
+		lines       float64
+		uniqueChars float64
+		topWords    int
+	}{
+		{"empty", "", 0, 0, 0, 0, 0},
+		{"single newline", "\n", 1, 0, 1, 1, 0},
+		{"whitespace only", "  \t ", 4, 0, 1, 2, 0},
+		{"two lines", "a b\nc", 5, 3, 2, 5, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/strings", fmt.Sprintf(`{"text":%q,"operation":"count"}`, tt.text))
+			if w.Code != http.StatusOK {
+				t.Fatalf("count: status = %d: %s", w.Code, w.Body)
+			}
+			resp := decodeJSON(t, w)
+			if resp["char_count"] != tt.chars || resp["word_count"] != tt.words || resp["line_count"] != tt.lines || resp["unique_chars"] != tt.uniqueChars {
+				t.Errorf("count: char_count = %v, word_count = %v, line_count = %v, unique_chars = %v, want %v, %v, %v, %v",
+					resp["char_count"], resp["word_count"], resp["line_count"], resp["unique_chars"], tt.chars, tt.words, tt.lines, tt.uniqueChars)
+			}
+
+			w = doRequest(r, http.MethodPost, "/process/strings", fmt.Sprintf(`{"text":%q,"operation":"pattern"}`, tt.text))
+			if w.Code != http.StatusOK {
+				t.Fatalf("pattern: status = %d: %s", w.Code, w.Body)
+			}
+			// An array even when there are no words, never null
+			topWords, ok := decodeJSON(t, w)["top_words"].([]interface{})
+			if !ok || len(topWords) != tt.topWords {
+				t.Errorf("pattern: top_words = %v, want an array of %d", topWords, tt.topWords)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MatrixRequest struct {
+	// Side length of the square matrices (default 128, capped by -max-matrix-size)
+	Size int `json:"size"`
+}
+
+// handleMatrix multiplies two deterministic size×size matrices with the
+// naive triple loop. Entries are small integers, so every product and sum
+// is exact in float64 and the checksum is identical across languages.
+func handleMatrix(c *gin.Context) {
+	var req MatrixRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Size == 0 {
+		req.Size = 128
+	}
+	if req.Size < 1 || req.Size > config.MaxMatrixSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid size: must be between 1 and %d", config.MaxMatrixSize)})
+		return
+	}
+
+	startTime := time.Now()
+	cpuTimer := startCPUTimer(c)
+
+	n := req.Size
+	a := make([]float64, n*n)
+	b := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			a[i*n+j] = float64((i*j)%7 + 1)
+			b[i*n+j] = float64((i+j)%5 + 1)
+		}
+	}
+
+	product := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		if timedOut(c) {
+			return
+		}
+		for j := 0; j < n; j++ {
+			sum := 0.0
+			for k := 0; k < n; k++ {
+				sum += a[i*n+k] * b[k*n+j]
+			}
+			product[i*n+j] = sum
+		}
+	}
+
+	checksum := 0.0
+	for _, v := range product {
+		checksum += v
+	}
+
+	endTime := time.Now()
+	setServerTimingTotal(c, endTime.Sub(startTime))
+	result := gin.H{
+		"size":                   n,
+		"checksum":               checksum,
+		"trace":                  matrixTrace(product, n),
+		"execution_time_seconds": endTime.Sub(startTime).Seconds(),
+		"execution_time_ns":      endTime.Sub(startTime).Nanoseconds(),
+		"service":                "Go Gin",
+	}
+	cpuTimer.record(result)
+	reportGCDisabled(c, result)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// matrixTrace sums the diagonal of an n×n row-major matrix.
+func matrixTrace(m []float64, n int) float64 {
+	trace := 0.0
+	for i := 0; i < n; i++ {
+		trace += m[i*n+i]
+	}
+	return trace
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMatrixChecksumIsStable(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/matrix", handleMatrix)
+
+	// Worked out independently of the handler
+	const checksum, trace = 2746237.0, 42883.0
+	for run := 0; run < 3; run++ {
+		w := doRequest(r, http.MethodPost, "/process/matrix", `{"size":64}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body)
+		}
+		resp := decodeJSON(t, w)
+		if resp["checksum"] != checksum || resp["trace"] != trace {
+			t.Errorf("run %d: checksum = %v, trace = %v, want %v and %v", run, resp["checksum"], resp["trace"], checksum, trace)
+		}
+	}
+}
+
+func TestMatrixSizeCap(t *testing.T) {
+	setConfig(t, &config.MaxMatrixSize, 32)
+	r := gin.New()
+	r.POST("/process/matrix", handleMatrix)
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"size":32}`, http.StatusOK},
+		{`{"size":33}`, http.StatusBadRequest},
+		{`{"size":-1}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			if w := doRequest(r, http.MethodPost, "/process/matrix", tt.body); w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
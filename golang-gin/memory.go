@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Most allocate-and-discard rounds accepted by /process/memory
+const maxMemoryIterations = 1000
+
+type MemoryRequest struct {
+	// Size of each allocation (default 10, capped by -max-memory-mb)
+	SizeMB int `json:"size_mb"`
+
+	// Number of allocations made and dropped in turn (default 10)
+	Iterations int `json:"iterations"`
+}
+
+// handleMemory allocates a size_mb buffer per iteration, writes to every
+// page of it so the allocation can't be elided or left untouched, and
+// drops it, putting the garbage collector under steady pressure.
+func handleMemory(c *gin.Context) {
+	var req MemoryRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.SizeMB == 0 {
+		req.SizeMB = 10
+	}
+	if req.SizeMB < 1 || req.SizeMB > config.MaxMemoryMB {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid size_mb: must be between 1 and %d", config.MaxMemoryMB)})
+		return
+	}
+	if req.Iterations == 0 {
+		req.Iterations = 10
+	}
+	if req.Iterations < 1 || req.Iterations > maxMemoryIterations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid iterations: must be between 1 and %d", maxMemoryIterations)})
+		return
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	startTime := time.Now()
+	cpuTimer := startCPUTimer(c)
+
+	const pageSize = 4096
+	size := req.SizeMB << 20
+	checksum := 0
+	for i := 0; i < req.Iterations; i++ {
+		if timedOut(c) {
+			return
+		}
+		buf := make([]byte, size)
+		for j := 0; j < size; j += pageSize {
+			buf[j] = byte(i + j/pageSize)
+		}
+		// Read back from the far end so the writes are observable
+		checksum += int(buf[size-pageSize])
+	}
+
+	endTime := time.Now()
+	setServerTimingTotal(c, endTime.Sub(startTime))
+	runtime.ReadMemStats(&after)
+
+	result := gin.H{
+		"size_mb":                req.SizeMB,
+		"iterations":             req.Iterations,
+		"allocated_mb":           req.SizeMB * req.Iterations,
+		"checksum":               checksum,
+		"memstats_before":        memStatsSummary(&before),
+		"memstats_after":         memStatsSummary(&after),
+		"gc_cycles":              after.NumGC - before.NumGC,
+		"execution_time_seconds": endTime.Sub(startTime).Seconds(),
+		"service":                "Go Gin",
+	}
+	cpuTimer.record(result)
+	reportGCDisabled(c, result)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// memStatsSummary picks the heap and GC figures reported by /process/memory.
+func memStatsSummary(stats *runtime.MemStats) gin.H {
+	return gin.H{
+		"heap_alloc_bytes":  stats.HeapAlloc,
+		"heap_sys_bytes":    stats.HeapSys,
+		"total_alloc_bytes": stats.TotalAlloc,
+		"num_gc":            stats.NumGC,
+	}
+}
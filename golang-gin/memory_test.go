@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMemory(t *testing.T) {
+	setConfig(t, &config.MaxMemoryMB, 8)
+	r := gin.New()
+	r.POST("/process/memory", handleMemory)
+
+	tests := []struct {
+		name      string
+		body      string
+		status    int
+		allocated float64
+	}{
+		{"small", `{"size_mb":1,"iterations":3}`, http.StatusOK, 3},
+		{"at the cap", `{"size_mb":8,"iterations":1}`, http.StatusOK, 8},
+		{"above the cap", `{"size_mb":9,"iterations":1}`, http.StatusBadRequest, 0},
+		{"negative size", `{"size_mb":-1}`, http.StatusBadRequest, 0},
+		{"too many iterations", `{"size_mb":1,"iterations":100000}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/memory", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			resp := decodeJSON(t, w)
+			if resp["allocated_mb"] != tt.allocated {
+				t.Errorf("allocated_mb = %v, want %v", resp["allocated_mb"], tt.allocated)
+			}
+			for _, key := range []string{"memstats_before", "memstats_after", "gc_cycles", "execution_time_seconds"} {
+				if _, ok := resp[key]; !ok {
+					t.Errorf("response has no %s", key)
+				}
+			}
+		})
+	}
+}
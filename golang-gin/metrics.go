@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Latency buckets in seconds, from sub-millisecond hello-world requests up
+// to long CPU-intensive runs
+var latencyBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time spent handling HTTP requests, by route.",
+		Buckets: latencyBuckets,
+	}, []string{"route", "method"})
+)
+
+// recordMetrics counts every request and observes its latency for /metrics.
+// Requests are labelled with their route pattern rather than the raw path
+// so unknown URLs can't create unbounded label values.
+func recordMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		httpRequests.WithLabelValues(route, method, strconv.Itoa(c.Writer.Status())).Inc()
+		httpLatency.WithLabelValues(route, method).Observe(elapsed.Seconds())
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// scrapeCounter returns the value of the series with the given name and
+// labels from /metrics, or 0 if it hasn't been created yet.
+func scrapeCounter(t *testing.T, r *gin.Engine, series string) float64 {
+	t.Helper()
+	w := doRequest(r, http.MethodGet, "/metrics", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("/metrics status = %d", w.Code)
+	}
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatalf("bad value in %q: %v", scanner.Text(), err)
+			}
+			return v
+		}
+	}
+	return 0
+}
+
+func TestRecordMetrics(t *testing.T) {
+	r := gin.New()
+	r.Use(recordMetrics())
+	r.POST("/process/strings", handleStringProcessing)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	tests := []struct {
+		name   string
+		body   string
+		series string
+	}{
+		{"ok", `{"text":"hello world","operation":"count"}`, `http_requests_total{method="POST",route="/process/strings",status="200"}`},
+		{"bad request", `{"text":"hello world","operation":"nope"}`, `http_requests_total{method="POST",route="/process/strings",status="400"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := scrapeCounter(t, r, tt.series)
+			doRequest(r, http.MethodPost, "/process/strings", tt.body)
+			if after := scrapeCounter(t, r, tt.series); after != before+1 {
+				t.Errorf("%s went from %v to %v, want an increase of 1", tt.series, before, after)
+			}
+		})
+	}
+}
+
+func TestRecordMetricsLabelsUnmatchedRoutes(t *testing.T) {
+	r := gin.New()
+	r.Use(recordMetrics())
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	series := `http_requests_total{method="GET",route="unmatched",status="404"}`
+	before := scrapeCounter(t, r, series)
+	doRequest(r, http.MethodGet, "/no/such/path", "")
+	if after := scrapeCounter(t, r, series); after != before+1 {
+		t.Errorf("%s went from %v to %v, want an increase of 1", series, before, after)
+	}
+}
@@ -0,0 +1,566 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireAuth rejects requests without an Authorization header carrying
+// the configured bearer token. It is a no-op when no token is configured.
+func requireAuth() gin.HandlerFunc {
+	expected := []byte(config.AuthToken)
+	return func(c *gin.Context) {
+		if len(expected) == 0 {
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), expected) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid bearer token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+const requestIDKey = "request_id"
+
+// Incoming request IDs longer than this are replaced rather than echoed
+const maxRequestIDLength = 128
+
+// requestID tags every request with an ID, taken from X-Request-ID when the
+// client sends one and otherwise a random UUID, and echoes it back in the
+// response so client and server timings can be joined.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newUUID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+const serverTimingKey = "server_timing"
+
+// serverTiming collects the metrics reported in the Server-Timing header.
+type serverTiming struct {
+	start   time.Time
+	metrics []string
+	total   time.Duration
+}
+
+// addServerTiming records a named phase duration for the Server-Timing
+// header. It has no effect unless the serverTimingHeader middleware is
+// installed.
+func addServerTiming(c *gin.Context, name string, d time.Duration) {
+	if timing, ok := c.Get(serverTimingKey); ok {
+		t := timing.(*serverTiming)
+		t.metrics = append(t.metrics, formatServerTiming(name, d))
+	}
+}
+
+// setServerTimingTotal reports d, the execution time a handler puts in its
+// body, as the Server-Timing total, so the header and the body agree.
+func setServerTimingTotal(c *gin.Context, d time.Duration) {
+	if timing, ok := c.Get(serverTimingKey); ok {
+		timing.(*serverTiming).total = d
+	}
+}
+
+func formatServerTiming(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.3f", name, float64(d)/float64(time.Millisecond))
+}
+
+// serverTimingHeader emits a Server-Timing header with the handler's
+// phases plus a total. Headers can't change once the body starts, so the
+// header is written as the response is committed, with the total the
+// handler reported through setServerTimingTotal, or else the time up to
+// that point; nothing is buffered, and streams flush as usual.
+func serverTimingHeader() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timing := &serverTiming{start: time.Now()}
+		c.Set(serverTimingKey, timing)
+		original := c.Writer
+		c.Writer = &serverTimingWriter{ResponseWriter: original, timing: timing}
+		defer func() { c.Writer = original }()
+		c.Next()
+	}
+}
+
+// serverTimingWriter sets the Server-Timing header just before the
+// response is committed.
+type serverTimingWriter struct {
+	gin.ResponseWriter
+	timing *serverTiming
+}
+
+func (w *serverTimingWriter) setHeader() {
+	if w.ResponseWriter.Written() {
+		return
+	}
+	total := w.timing.total
+	if total == 0 {
+		total = time.Since(w.timing.start)
+	}
+	w.Header().Set("Server-Timing", strings.Join(append(w.timing.metrics, formatServerTiming("total", total)), ", "))
+}
+
+func (w *serverTimingWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *serverTimingWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *serverTimingWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *serverTimingWriter) Flush() {
+	w.setHeader()
+	w.ResponseWriter.Flush()
+}
+
+// allowCORS adds Access-Control-Allow-* headers for requests from the
+// comma-separated origins ("*" allows any), and answers preflight OPTIONS
+// requests itself with a 204.
+func allowCORS(origins, methods, headers string) gin.HandlerFunc {
+	allowed := make(map[string]bool)
+	for _, origin := range strings.Split(origins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			allowed[origin] = true
+		}
+	}
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || (!allowed["*"] && !allowed[origin]) {
+			c.Next()
+			return
+		}
+
+		if allowed["*"] {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID, Server-Timing")
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			c.Header("Access-Control-Max-Age", "600")
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// limitBodySize answers 413 to requests whose body is larger than maxBytes
+// (0 disables the check). The body is read up front through
+// http.MaxBytesReader, so handlers never see a partial body.
+func limitBodySize(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		tooLarge := gin.H{"error": fmt.Sprintf("Request body exceeds the maximum of %d bytes", maxBytes)}
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, tooLarge)
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// limitJSONDepth rejects request bodies whose JSON nesting exceeds
+// maxDepth, before any handler decodes or walks them.
+func limitJSONDepth(maxDepth int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxDepth <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if depth := jsonDepth(body); depth > maxDepth {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("JSON nesting depth %d exceeds the maximum of %d", depth, maxDepth),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// jsonDepth returns the maximum object/array nesting depth of data. It
+// scans brackets outside of string literals without decoding, so it
+// neither recurses nor allocates; malformed JSON is left to the decoder.
+func jsonDepth(data []byte) int {
+	depth, maxDepth := 0, 0
+	inString, escaped := false, false
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return maxDepth
+}
+
+// Header carrying the hex SHA-256 digest of the request body
+const bodyChecksumHeader = "X-Body-SHA256"
+
+// verifyBodyChecksum rejects requests whose body doesn't match the digest
+// in bodyChecksumHeader with a 422, or with a 400 when the header isn't a
+// hex SHA-256 digest. Requests without the header pass through.
+func verifyBodyChecksum() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := strings.TrimSpace(c.GetHeader(bodyChecksumHeader))
+		if expected == "" {
+			c.Next()
+			return
+		}
+		if digest, err := hex.DecodeString(expected); err != nil || len(digest) != sha256.Size {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": bodyChecksumHeader + " must be a SHA-256 digest of 64 hex characters"})
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+				return
+			}
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		actual := hex.EncodeToString(sum[:])
+		if !strings.EqualFold(actual, expected) {
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error":    "Request body does not match " + bodyChecksumHeader,
+				"expected": expected,
+				"actual":   actual,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimit answers 429 once requests arrive faster than the rate_limit
+// setting, after allowing a burst of up to rate_burst. The limit is shared
+// by all clients and follows the settings across reloads; it is a no-op
+// while rate_limit is 0. Health checks and /metrics are never limited, so
+// a flood can't get the server restarted or hide itself from monitoring.
+func rateLimit() gin.HandlerFunc {
+	bucket := &tokenBucket{}
+	return func(c *gin.Context) {
+		settings := config.Settings()
+		if settings.RateLimit <= 0 {
+			c.Next()
+			return
+		}
+		if path := c.Request.URL.Path; strings.HasPrefix(path, "/health") || path == "/metrics" {
+			c.Next()
+			return
+		}
+		burst := settings.RateBurst
+		if burst <= 0 {
+			burst = settings.RateLimit
+		}
+		if wait := bucket.take(float64(settings.RateLimit), float64(burst)); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// tokenBucket refills at rate tokens per second up to burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// take spends a token if one is available and returns 0, or otherwise
+// returns how long until the next one. A change of rate or burst, as after
+// a reload, starts the bucket over full.
+func (b *tokenBucket) take(rate, burst float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if rate != b.rate || burst != b.burst {
+		b.rate, b.burst, b.tokens = rate, burst, burst
+	} else {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// limitGoroutines sheds /process requests with a 503 while the number of
+// goroutines is above maxGoroutines. It is a no-op when maxGoroutines is 0.
+func limitGoroutines(maxGoroutines int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxGoroutines > 0 && runtime.NumGoroutine() > maxGoroutines {
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Server is over its goroutine limit"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// limitCPUWorkers returns middleware that lets at most workers requests
+// through at once. With the "reject" policy the rest get a 429; with
+// "queue" they wait for a slot until the request's deadline passes. It is a
+// no-op when workers is 0. Each call makes a separate pool.
+func limitCPUWorkers(workers int, policy string) gin.HandlerFunc {
+	if workers <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	slots := make(chan struct{}, workers)
+	return func(c *gin.Context) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			if policy == "reject" {
+				c.Header("Retry-After", "1")
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "All CPU workers are busy"})
+				return
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-c.Request.Context().Done():
+				timedOut(c)
+				c.Abort()
+				return
+			}
+		}
+		defer func() { <-slots }()
+		c.Next()
+	}
+}
+
+// timeoutRequests gives each request a context deadline of timeout (0
+// disables it). Handlers are expected to check timedOut between chunks of
+// work and stop once the deadline has passed.
+func timeoutRequests(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// timedOut reports whether the request's context has ended, answering 503
+// if so and nothing has been written yet.
+func timedOut(c *gin.Context) bool {
+	if c.Request.Context().Err() == nil {
+		return false
+	}
+	if buffered, ok := c.Writer.(*bufferedWriter); ok {
+		// Nothing has reached the client yet: drop what the handler
+		// buffered and leave the 503 to includeSerializationTime
+		buffered.discard()
+		c.Abort()
+		return true
+	}
+	if !c.Writer.Written() {
+		abortTimedOut(c)
+	}
+	return true
+}
+
+func abortTimedOut(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Request timed out"})
+}
+
+// rejectInMaintenance answers 503 while maintenance mode is on.
+func rejectInMaintenance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.Settings().Maintenance {
+			c.Header("Retry-After", "30")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Server is in maintenance mode"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// includeSerializationTime, when the request carries X-Include-Serialization,
+// times the handler together with its JSON encoding and adds the result to
+// the response as total_time_with_serialization_ns. The response is
+// buffered so the field can be spliced in after encoding has finished.
+func includeSerializationTime() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("X-Include-Serialization") == "" {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+		c.Next()
+		elapsed := time.Since(start)
+		c.Writer = original
+
+		body, ok := buffered.close()
+		if !ok {
+			if !original.Written() {
+				abortTimedOut(c)
+			}
+			return
+		}
+		if strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") && len(body) > 1 && body[0] == '{' {
+			field := fmt.Sprintf(`{"total_time_with_serialization_ns":%d`, elapsed.Nanoseconds())
+			if body[1] != '}' {
+				field += ","
+			}
+			body = append([]byte(field), body[1:]...)
+		}
+		original.Write(body)
+	}
+}
+
+// bufferedWriter holds the response body back from the client. The status
+// code still goes to the wrapped writer, which defers it until the first write.
+// Once the request has timed out, or the body has been taken by close,
+// further writes are dropped.
+type bufferedWriter struct {
+	gin.ResponseWriter
+
+	mu       sync.Mutex
+	body     bytes.Buffer
+	timedOut bool
+	closed   bool
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.closed {
+		return len(data), nil
+	}
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.closed {
+		return len(s), nil
+	}
+	return w.body.WriteString(s)
+}
+
+// discard throws away the buffered body and everything written after it,
+// for a request that has timed out.
+func (w *bufferedWriter) discard() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	w.body.Reset()
+}
+
+// close stops further buffering and returns the body, or false if the
+// request timed out and the body was discarded.
+func (w *bufferedWriter) close() ([]byte, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return w.body.Bytes(), !w.timedOut
+}
@@ -0,0 +1,661 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServerTimingHeader(t *testing.T) {
+	r := gin.New()
+	r.Use(serverTimingHeader())
+	r.POST("/process/cpu-intensive", handleCPUIntensive)
+	r.GET("/process/cpu-intensive", handleCPUIntensive)
+
+	metric := regexp.MustCompile(`^([a-z]+);dur=\d+\.\d{3}$`)
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		status  int
+		metrics []string
+	}{
+		{"post", http.MethodPost, "/process/cpu-intensive", `{"n":20}`, http.StatusOK, []string{"fib", "primes", "total"}},
+		{"get", http.MethodGet, "/process/cpu-intensive?n=20", "", http.StatusOK, []string{"fib", "primes", "total"}},
+		{"rejected", http.MethodPost, "/process/cpu-intensive", `{"n":-1}`, http.StatusBadRequest, []string{"total"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, tt.method, tt.path, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			header := w.Header().Get("Server-Timing")
+			if header == "" {
+				t.Fatal("no Server-Timing header")
+			}
+			var names []string
+			for _, m := range strings.Split(header, ", ") {
+				match := metric.FindStringSubmatch(m)
+				if match == nil {
+					t.Fatalf("malformed metric %q in %q", m, header)
+				}
+				names = append(names, match[1])
+			}
+			if strings.Join(names, ",") != strings.Join(tt.metrics, ",") {
+				t.Errorf("metrics = %v, want %v", names, tt.metrics)
+			}
+		})
+	}
+}
+
+func TestServerTimingTotalMatchesExecutionTime(t *testing.T) {
+	r := gin.New()
+	r.Use(serverTimingHeader())
+	r.POST("/process/cpu-intensive", handleCPUIntensive)
+	r.GET("/process/cpu-intensive", handleCPUIntensive)
+	r.POST("/process/sort", handleSort)
+	r.POST("/process/matrix", handleMatrix)
+
+	total := regexp.MustCompile(`(?:^|, )total;dur=(\d+\.\d{3})$`)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"cpu-intensive post", http.MethodPost, "/process/cpu-intensive", `{"n":25}`},
+		{"cpu-intensive get", http.MethodGet, "/process/cpu-intensive?n=25", ""},
+		{"sort", http.MethodPost, "/process/sort", `{"count":1000}`},
+		{"matrix", http.MethodPost, "/process/matrix", `{"size":32}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, tt.method, tt.path, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+			match := total.FindStringSubmatch(w.Header().Get("Server-Timing"))
+			if match == nil {
+				t.Fatalf("no total in Server-Timing %q", w.Header().Get("Server-Timing"))
+			}
+			ns, _ := decodeJSON(t, w)["execution_time_ns"].(float64)
+			if want := fmt.Sprintf("%.3f", ns/1e6); match[1] != want {
+				t.Errorf("total = %s ms, want execution_time_ns %v as %s ms", match[1], ns, want)
+			}
+		})
+	}
+}
+
+func TestServerTimingHeaderWhenStreaming(t *testing.T) {
+	r := gin.New()
+	r.Use(serverTimingHeader())
+	r.GET("/stream", func(c *gin.Context) {
+		addServerTiming(c, "setup", time.Millisecond)
+		c.String(http.StatusOK, "first")
+		c.Writer.Flush()
+		addServerTiming(c, "late", time.Millisecond)
+		c.String(http.StatusOK, "second")
+	})
+
+	w := doRequest(r, http.MethodGet, "/stream", "")
+	if w.Body.String() != "firstsecond" {
+		t.Fatalf("body = %q", w.Body)
+	}
+	if !w.Flushed {
+		t.Error("the first chunk was held back instead of flushed")
+	}
+	// Phases recorded after the header went out can't be reported
+	header := w.Header().Get("Server-Timing")
+	if !regexp.MustCompile(`^setup;dur=1\.000, total;dur=\d+\.\d{3}$`).MatchString(header) {
+		t.Errorf("Server-Timing = %q, want setup and total", header)
+	}
+}
+
+func TestLimitJSONDepth(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/json", limitJSONDepth(4), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	nested := func(depth int) string {
+		return strings.Repeat(`{"a":`, depth) + "1" + strings.Repeat("}", depth)
+	}
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"at the limit", nested(4), http.StatusOK},
+		{"beyond the limit", nested(5), http.StatusBadRequest},
+		{"arrays count too", `[[[[[1]]]]]`, http.StatusBadRequest},
+		{"brackets inside strings", `{"a":"[[[[[[[["}`, http.StatusOK},
+		{"escaped quote inside string", `{"a":"\"[[[[[[["}`, http.StatusOK},
+		{"far beyond the limit", nested(10000), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/json", tt.body)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestTimeoutRequests(t *testing.T) {
+	r := gin.New()
+	process := r.Group("/process", timeoutRequests(5*time.Millisecond))
+	process.POST("/io", handleIO)
+	process.POST("/cpu-intensive", handleCPUIntensive)
+	serialized := r.Group("/serialized", timeoutRequests(5*time.Millisecond), includeSerializationTime())
+	serialized.POST("/cpu-intensive", handleCPUIntensive)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"fast", "/process/io", `{"delay_ms":0}`, http.StatusOK},
+		{"slow io", "/process/io", `{"delay_ms":2000}`, http.StatusServiceUnavailable},
+		{"slow cpu", "/process/cpu-intensive", `{"n":10,"prime_limit":10000000}`, http.StatusServiceUnavailable},
+		{"slow cpu with a buffered body", "/serialized/cpu-intensive", `{"n":10,"prime_limit":10000000}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Include-Serialization", "1")
+			w := httptest.NewRecorder()
+			start := time.Now()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status == http.StatusServiceUnavailable {
+				if elapsed := time.Since(start); elapsed > time.Second {
+					t.Errorf("took %s to time out", elapsed)
+				}
+				if body := w.Body.String(); body != `{"error":"Request timed out"}` {
+					t.Errorf("body = %s, want a single timeout error", body)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	r := gin.New()
+	r.Use(requestID())
+	r.GET("/", handleHelloWorld)
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	tests := []struct {
+		name     string
+		incoming string
+		echoed   bool
+	}{
+		{"generated", "", false},
+		{"echoed", "bench-run-42", true},
+		{"too long", strings.Repeat("x", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Request-ID", tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get("X-Request-ID")
+			if tt.echoed && id != tt.incoming {
+				t.Errorf("X-Request-ID = %q, want %q", id, tt.incoming)
+			}
+			if !tt.echoed && !uuid.MatchString(id) {
+				t.Errorf("X-Request-ID = %q, want a random UUID", id)
+			}
+		})
+	}
+}
+
+func TestLimitBodySize(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/strings", limitBodySize(64), handleStringProcessing)
+
+	small := `{"text":"hello","operation":"count"}`
+	large := fmt.Sprintf(`{"text":%q,"operation":"count"}`, strings.Repeat("a", 100))
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		status  int
+	}{
+		{"within the limit", small, false, http.StatusOK},
+		{"oversized", large, false, http.StatusRequestEntityTooLarge},
+		{"within the limit, no length", small, true, http.StatusOK},
+		{"oversized, no length", large, true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/process/strings", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.chunked {
+				// The size is only found out by reading
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestLimitCPUWorkers(t *testing.T) {
+	const workers, requests = 2, 8
+	tests := []struct {
+		policy   string
+		timeout  time.Duration
+		statuses map[int]int
+	}{
+		{"queue", 0, map[int]int{http.StatusOK: requests}},
+		{"reject", 0, map[int]int{http.StatusOK: workers, http.StatusTooManyRequests: requests - workers}},
+		// Each request holds its slot for 200ms, so only the first two
+		// batches get one before a 300ms deadline
+		{"queue", 300 * time.Millisecond, map[int]int{http.StatusOK: 2 * workers, http.StatusServiceUnavailable: requests - 2*workers}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%s", tt.policy, tt.timeout), func(t *testing.T) {
+			var mu sync.Mutex
+			running, peak := 0, 0
+			r := gin.New()
+			r.POST("/process/cpu-intensive", timeoutRequests(tt.timeout), limitCPUWorkers(workers, tt.policy), func(c *gin.Context) {
+				mu.Lock()
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+				time.Sleep(200 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				c.Status(http.StatusOK)
+			})
+
+			recorders := make([]*httptest.ResponseRecorder, requests)
+			var wg sync.WaitGroup
+			for i := range recorders {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					recorders[i] = doRequest(r, http.MethodPost, "/process/cpu-intensive", "")
+				}(i)
+			}
+			wg.Wait()
+
+			statuses := map[int]int{}
+			for _, w := range recorders {
+				statuses[w.Code]++
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+					t.Error("429 without Retry-After")
+				}
+			}
+			if !reflect.DeepEqual(statuses, tt.statuses) {
+				t.Errorf("statuses = %v, want %v", statuses, tt.statuses)
+			}
+			if peak > workers {
+				t.Errorf("%d requests ran at once, want at most %d", peak, workers)
+			}
+		})
+	}
+}
+
+func TestLimitGoroutines(t *testing.T) {
+	// Goroutines parked until the end of the test
+	const parked = 50
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	park := func() {
+		for i := 0; i < parked; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-release
+			}()
+		}
+	}
+	t.Cleanup(func() {
+		close(release)
+		wg.Wait()
+	})
+
+	tests := []struct {
+		name   string
+		limit  func() int
+		park   bool
+		status int
+	}{
+		{"disabled", func() int { return 0 }, false, http.StatusOK},
+		{"well under the limit", func() int { return runtime.NumGoroutine() + 1000 }, false, http.StatusOK},
+		{"over the limit", func() int { return 1 }, false, http.StatusServiceUnavailable},
+		{"pushed over by other goroutines", func() int { return runtime.NumGoroutine() + parked/2 }, true, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(limitGoroutines(tt.limit()))
+			r.GET("/process/normal", func(c *gin.Context) { c.Status(http.StatusOK) })
+			if tt.park {
+				park()
+			}
+
+			w := doRequest(r, http.MethodGet, "/process/normal", "")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if retryAfter := w.Header().Get("Retry-After"); (retryAfter == "1") != (tt.status == http.StatusServiceUnavailable) {
+				t.Errorf("Retry-After = %q for status %d", retryAfter, w.Code)
+			}
+		})
+	}
+}
+
+func TestAllowCORS(t *testing.T) {
+	const methods, headers = "GET, POST, OPTIONS", "Content-Type, Authorization"
+	tests := []struct {
+		name      string
+		origins   string
+		method    string
+		origin    string
+		status    int
+		allowed   string
+		preflight bool
+	}{
+		{"preflight from a listed origin", "http://dash.local, http://other.local", http.MethodOptions, "http://dash.local", http.StatusNoContent, "http://dash.local", true},
+		{"preflight from any origin", "*", http.MethodOptions, "http://dash.local", http.StatusNoContent, "*", true},
+		{"preflight from an unlisted origin", "http://other.local", http.MethodOptions, "http://dash.local", http.StatusNotFound, "", false},
+		{"simple request", "http://dash.local", http.MethodPost, "http://dash.local", http.StatusOK, "http://dash.local", false},
+		{"same-origin request", "http://dash.local", http.MethodPost, "", http.StatusOK, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(allowCORS(tt.origins, methods, headers))
+			r.POST("/process/normal", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/process/normal", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+				req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowed {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.allowed)
+			}
+			want := map[string]string{"Access-Control-Allow-Methods": "", "Access-Control-Allow-Headers": "", "Access-Control-Max-Age": ""}
+			if tt.preflight {
+				want = map[string]string{"Access-Control-Allow-Methods": methods, "Access-Control-Allow-Headers": headers, "Access-Control-Max-Age": "600"}
+			}
+			for header, value := range want {
+				if got := w.Header().Get(header); got != value {
+					t.Errorf("%s = %q, want %q", header, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	setSettings := func(rate, burst int) {
+		config.mu.Lock()
+		config.settings.RateLimit, config.settings.RateBurst = rate, burst
+		config.mu.Unlock()
+	}
+	old := config.Settings()
+	t.Cleanup(func() { setSettings(old.RateLimit, old.RateBurst) })
+
+	tests := []struct {
+		name     string
+		rate     int
+		burst    int
+		path     string
+		requests int
+		status   int
+	}{
+		{"disabled", 0, 0, "/process/normal", 5, http.StatusOK},
+		{"within the burst", 1, 3, "/process/normal", 3, http.StatusOK},
+		{"burst defaults to the rate", 2, 0, "/process/normal", 3, http.StatusTooManyRequests},
+		{"over the burst", 1, 2, "/process/normal", 3, http.StatusTooManyRequests},
+		{"health checks are exempt", 1, 1, "/health/live", 5, http.StatusOK},
+		{"metrics are exempt", 1, 1, "/metrics", 5, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setSettings(tt.rate, tt.burst)
+			r := gin.New()
+			r.Use(rateLimit())
+			r.GET(tt.path, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			var w *httptest.ResponseRecorder
+			for i := 0; i < tt.requests; i++ {
+				if w = doRequest(r, http.MethodGet, tt.path, ""); i < tt.requests-1 && w.Code != http.StatusOK {
+					t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, http.StatusOK)
+				}
+			}
+			if w.Code != tt.status {
+				t.Fatalf("last request: status = %d, want %d", w.Code, tt.status)
+			}
+			retryAfter := w.Header().Get("Retry-After")
+			if tt.status == http.StatusTooManyRequests && retryAfter != "1" {
+				t.Errorf("Retry-After = %q, want %q", retryAfter, "1")
+			} else if tt.status == http.StatusOK && retryAfter != "" {
+				t.Errorf("Retry-After = %q, want none", retryAfter)
+			}
+		})
+	}
+}
+
+func TestIncludeSerializationTime(t *testing.T) {
+	r := gin.New()
+	r.Use(includeSerializationTime())
+	r.GET("/object", func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"a": 1}) })
+	r.GET("/empty", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	r.GET("/array", func(c *gin.Context) { c.JSON(http.StatusOK, []int{1, 2}) })
+	r.GET("/text", func(c *gin.Context) { c.String(http.StatusOK, "{not json}") })
+
+	withTime := regexp.MustCompile(`^\{"total_time_with_serialization_ns":[1-9][0-9]*(,|\})`)
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		status int
+		timed  bool
+		body   string
+	}{
+		{"object", "/object", "1", http.StatusCreated, true, `"a":1}`},
+		{"empty object", "/empty", "true", http.StatusOK, true, ``},
+		{"not requested", "/object", "", http.StatusCreated, false, `{"a":1}`},
+		{"array left alone", "/array", "1", http.StatusOK, false, `[1,2]`},
+		{"not JSON", "/text", "1", http.StatusOK, false, `{not json}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Include-Serialization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			body := w.Body.String()
+			if timed := withTime.MatchString(body); timed != tt.timed {
+				t.Fatalf("body = %s, want timing field %v", body, tt.timed)
+			}
+			if tt.timed {
+				body = withTime.ReplaceAllString(body, "")
+			}
+			if body != tt.body {
+				t.Errorf("rest of body = %s, want %s", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestRejectInMaintenance(t *testing.T) {
+	setMaintenance := func(on bool) {
+		config.mu.Lock()
+		config.settings.Maintenance = on
+		config.mu.Unlock()
+	}
+	t.Cleanup(func() { setMaintenance(false) })
+
+	r := gin.New()
+	registerWorkloads(r, limitCPUWorkers(0, ""), rejectInMaintenance())
+	r.GET("/health/live", handleLiveness)
+	r.POST("/gc", rejectInMaintenance(), handleForceGC)
+
+	const body = `{"operation":"reverse","text":"abc"}`
+	tests := []struct {
+		name        string
+		maintenance bool
+		method      string
+		path        string
+		status      int
+	}{
+		{"process off", false, http.MethodPost, "/process/strings", http.StatusOK},
+		{"process on", true, http.MethodPost, "/process/strings", http.StatusServiceUnavailable},
+		{"cpu-intensive on", true, http.MethodGet, "/process/cpu-intensive?n=10", http.StatusServiceUnavailable},
+		{"forced GC on", true, http.MethodPost, "/gc", http.StatusServiceUnavailable},
+		{"hello world on", true, http.MethodGet, "/", http.StatusOK},
+		{"liveness on", true, http.MethodGet, "/health/live", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setMaintenance(tt.maintenance)
+			w := doRequest(r, tt.method, tt.path, body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			retryAfter := w.Header().Get("Retry-After")
+			if tt.status == http.StatusServiceUnavailable && retryAfter != "30" {
+				t.Errorf("Retry-After = %q, want %q", retryAfter, "30")
+			} else if tt.status == http.StatusOK && retryAfter != "" {
+				t.Errorf("Retry-After = %q, want none", retryAfter)
+			}
+		})
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{"no token configured", "", "", http.StatusOK},
+		{"no token configured ignores the header", "", "Bearer anything", http.StatusOK},
+		{"correct token", "s3cret", "Bearer s3cret", http.StatusOK},
+		{"missing header", "s3cret", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "Bearer wrong!", http.StatusUnauthorized},
+		{"prefix of the token", "s3cret", "Bearer s3cre", http.StatusUnauthorized},
+		{"token with a suffix", "s3cret", "Bearer s3cret2", http.StatusUnauthorized},
+		{"different case", "s3cret", "Bearer S3CRET", http.StatusUnauthorized},
+		{"empty bearer", "s3cret", "Bearer ", http.StatusUnauthorized},
+		{"other scheme", "s3cret", "Basic s3cret", http.StatusUnauthorized},
+		{"no scheme", "s3cret", "s3cret", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, &config.AuthToken, tt.token)
+			r := gin.New()
+			r.GET("/process/normal", requireAuth(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/process/normal", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if challenge := w.Header().Get("WWW-Authenticate"); (tt.status == http.StatusUnauthorized) != (challenge == "Bearer") {
+				t.Errorf("WWW-Authenticate = %q with status %d", challenge, w.Code)
+			}
+		})
+	}
+}
+
+func TestVerifyBodyChecksum(t *testing.T) {
+	const body = `{"name":"Ada"}`
+	// sha256sum of body
+	const digest = "88bab6d8f6dc68a877064d584cbb5b6c50e74f617ea50d81d3a53c2ee6ffbc4f"
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"matching digest", digest, http.StatusOK},
+		{"uppercase digest", strings.ToUpper(digest), http.StatusOK},
+		{"surrounding space", " " + digest + " ", http.StatusOK},
+		{"missing header", "", http.StatusOK},
+		{"mismatch", strings.Repeat("0", 64), http.StatusUnprocessableEntity},
+		{"not hex", strings.Repeat("z", 64), http.StatusBadRequest},
+		{"too short", digest[:62], http.StatusBadRequest},
+		{"odd length", digest[:63], http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(verifyBodyChecksum())
+			var received string
+			r.POST("/process/normal", func(c *gin.Context) {
+				data, _ := c.GetRawData()
+				received = string(data)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/process/normal", strings.NewReader(body))
+			if tt.header != "" {
+				req.Header.Set(bodyChecksumHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			// The checked body is still there for the handler
+			if tt.status == http.StatusOK && received != body {
+				t.Errorf("handler read %q, want %q", received, body)
+			}
+			if tt.status == http.StatusUnprocessableEntity && !strings.Contains(w.Body.String(), `"actual":"`+digest+`"`) {
+				t.Errorf("body = %s, want the actual digest", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Most goroutines /process/parallel will fan out to
+const maxParallelWorkers = 1024
+
+type ParallelRequest struct {
+	Workers int `json:"workers"`
+	N       int `json:"n"`
+}
+
+// workerTiming is how long one /process/parallel goroutine ran for.
+type workerTiming struct {
+	Worker  int     `json:"worker"`
+	Seconds float64 `json:"seconds"`
+}
+
+// handleParallel computes fibonacci(n) on each of `workers` goroutines and
+// joins them, so the scheduler has to spread the work over GOMAXPROCS.
+func handleParallel(c *gin.Context) {
+	var req ParallelRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Workers == 0 {
+		req.Workers = runtime.GOMAXPROCS(0)
+	}
+	if req.Workers < 1 || req.Workers > maxParallelWorkers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid workers: must be between 1 and %d", maxParallelWorkers)})
+		return
+	}
+	if req.N == 0 {
+		req.N = 10000
+	}
+	if req.N < 1 || req.N > config.MaxN {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid n: must be between 1 and %d", config.MaxN)})
+		return
+	}
+
+	startTime := time.Now()
+
+	timings := make([]workerTiming, req.Workers)
+	results := make([]string, req.Workers)
+	var wg sync.WaitGroup
+	for i := 0; i < req.Workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			start := time.Now()
+			if req.N > maxIntFibonacciN {
+				results[worker] = fibonacciBig(c.Request.Context(), req.N).String()
+			} else {
+				results[worker] = strconv.Itoa(fibonacci(req.N))
+			}
+			timings[worker] = workerTiming{Worker: worker, Seconds: time.Since(start).Seconds()}
+		}(i)
+	}
+	wg.Wait()
+	if timedOut(c) {
+		return
+	}
+
+	endTime := time.Now()
+	setServerTimingTotal(c, endTime.Sub(startTime))
+
+	// Every worker computes the same value, so any mismatch is a bug
+	for _, r := range results[1:] {
+		if r != results[0] {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Workers disagreed on the result"})
+			return
+		}
+	}
+
+	workerSeconds := 0.0
+	for _, t := range timings {
+		workerSeconds += t.Seconds
+	}
+
+	result := gin.H{
+		"workers":                req.Workers,
+		"n":                      req.N,
+		"gomaxprocs":             runtime.GOMAXPROCS(0),
+		"num_cpu":                runtime.NumCPU(),
+		"worker_timings":         timings,
+		"worker_seconds_total":   workerSeconds,
+		"execution_time_seconds": endTime.Sub(startTime).Seconds(),
+		"service":                "Go Gin",
+	}
+	// Reported the same way as /process/cpu-intensive
+	if req.N > maxIntFibonacciN {
+		result["fibonacci_result_big"] = results[0]
+	} else {
+		result["fibonacci_result"] = fibonacci(req.N)
+	}
+	reportGCDisabled(c, result)
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParallel(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/parallel", handleParallel)
+
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		workers int
+	}{
+		{"four workers", `{"workers":4,"n":30}`, http.StatusOK, 4},
+		{"defaults to GOMAXPROCS", "", http.StatusOK, runtime.GOMAXPROCS(0)},
+		{"too many workers", `{"workers":100000}`, http.StatusBadRequest, 0},
+		{"negative n", `{"workers":4,"n":-1}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/parallel", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Workers       int            `json:"workers"`
+				GOMAXPROCS    int            `json:"gomaxprocs"`
+				WorkerTimings []workerTiming `json:"worker_timings"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Workers != tt.workers || len(resp.WorkerTimings) != tt.workers {
+				t.Errorf("workers = %d with %d timings, want %d", resp.Workers, len(resp.WorkerTimings), tt.workers)
+			}
+			if resp.GOMAXPROCS != runtime.GOMAXPROCS(0) {
+				t.Errorf("gomaxprocs = %d, want %d", resp.GOMAXPROCS, runtime.GOMAXPROCS(0))
+			}
+			for i, timing := range resp.WorkerTimings {
+				if timing.Worker != i {
+					t.Errorf("timing %d is for worker %d", i, timing.Worker)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerPprof serves the net/http/pprof handlers under /debug/pprof for
+// go tool pprof. It is only called when -pprof is set.
+func registerPprof(r *gin.Engine) {
+	debug := r.Group("/debug/pprof")
+	debug.GET("/", gin.WrapF(pprof.Index))
+	debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/profile", gin.WrapF(pprof.Profile))
+	debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+	debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/trace", gin.WrapF(pprof.Trace))
+	// Index serves the named profiles (heap, goroutine, allocs, ...)
+	debug.GET("/:profile", gin.WrapF(pprof.Index))
+}
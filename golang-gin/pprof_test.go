@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPprof(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		path    string
+		status  int
+	}{
+		{"enabled index", true, "/debug/pprof/", http.StatusOK},
+		{"enabled named profile", true, "/debug/pprof/heap", http.StatusOK},
+		{"enabled cmdline", true, "/debug/pprof/cmdline", http.StatusOK},
+		{"disabled index", false, "/debug/pprof/", http.StatusNotFound},
+		{"disabled named profile", false, "/debug/pprof/heap", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			if tt.enabled {
+				registerPprof(r)
+			}
+			if w := doRequest(r, http.MethodGet, tt.path, ""); w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxPrimeBudgetMS = 60000
+
+// Numbers sieved between deadline checks; big enough that checking the
+// clock costs nothing measurable, small enough to stop close to the budget
+const primeSegmentSize = 1 << 16
+
+type PrimesRequest struct {
+	// How long to keep finding primes (default 100, at most the
+	// -request-timeout)
+	BudgetMS int `json:"budget_ms"`
+}
+
+// handlePrimes counts primes with a segmented sieve until budget_ms has
+// passed or the request's context ends, and reports how far it got.
+func handlePrimes(c *gin.Context) {
+	var req PrimesRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.BudgetMS == 0 {
+		req.BudgetMS = 100
+	}
+	if req.BudgetMS < 1 || req.BudgetMS > maxPrimeBudgetMS {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid budget_ms: must be between 1 and %d", maxPrimeBudgetMS)})
+		return
+	}
+	// A budget past the request's deadline could never be used up
+	if timeout := config.RequestTimeout; timeout > 0 && time.Duration(req.BudgetMS)*time.Millisecond > timeout {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid budget_ms: must not exceed the request timeout of %d ms", timeout.Milliseconds())})
+		return
+	}
+
+	startTime := time.Now()
+	cpuTimer := startCPUTimer(c)
+	deadline := startTime.Add(time.Duration(req.BudgetMS) * time.Millisecond)
+	searched, count, largest, stoppedBy := primesUntil(c.Request.Context(), deadline)
+	endTime := time.Now()
+	setServerTimingTotal(c, endTime.Sub(startTime))
+
+	result := gin.H{
+		"budget_ms":              req.BudgetMS,
+		"searched_up_to":         searched,
+		"primes_count":           count,
+		"largest_prime":          largest,
+		"stopped_by":             stoppedBy,
+		"execution_time_seconds": endTime.Sub(startTime).Seconds(),
+		"execution_time_ns":      endTime.Sub(startTime).Nanoseconds(),
+		"service":                "Go Gin",
+	}
+	cpuTimer.record(result)
+	reportGCDisabled(c, result)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// primesUntil sieves successive segments of primeSegmentSize numbers,
+// checking the deadline and ctx between segments. It returns the highest
+// number examined, the primes found up to it, the largest of them, and
+// whether the "budget" or the "context" ended the search.
+func primesUntil(ctx context.Context, deadline time.Time) (searched, count, largest int, stoppedBy string) {
+	composite := make([]bool, primeSegmentSize)
+	var base []int
+	baseLimit := 1
+	for low := 2; ; low += primeSegmentSize {
+		high := low + primeSegmentSize - 1
+		if root := int(math.Sqrt(float64(high))); root > baseLimit {
+			baseLimit = 2 * root
+			base = eratosthenesPrimes(context.Background(), baseLimit)
+		}
+
+		clear(composite)
+		for _, p := range base {
+			if p*p > high {
+				break
+			}
+			start := max(p*p, (low+p-1)/p*p)
+			for i := start; i <= high; i += p {
+				composite[i-low] = true
+			}
+		}
+		for i, isComposite := range composite {
+			if !isComposite {
+				count++
+				largest = low + i
+			}
+		}
+		searched = high
+
+		if ctx.Err() != nil {
+			return searched, count, largest, "context"
+		}
+		if !time.Now().Before(deadline) {
+			return searched, count, largest, "budget"
+		}
+	}
+}
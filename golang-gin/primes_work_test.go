@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPrimesBudget(t *testing.T) {
+	setConfig(t, &config.RequestTimeout, 300*time.Millisecond)
+	r := gin.New()
+	r.POST("/process/primes", handlePrimes)
+
+	// Slack for the last segment and for a busy test machine
+	const slack = 150 * time.Millisecond
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		budget time.Duration
+	}{
+		{"short budget", `{"budget_ms":20}`, http.StatusOK, 20 * time.Millisecond},
+		{"default budget", "", http.StatusOK, 100 * time.Millisecond},
+		{"at the request timeout", `{"budget_ms":300}`, http.StatusOK, 300 * time.Millisecond},
+		{"above the request timeout", `{"budget_ms":301}`, http.StatusBadRequest, 0},
+		{"negative budget", `{"budget_ms":-1}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			w := doRequest(r, http.MethodPost, "/process/primes", tt.body)
+			elapsed := time.Since(start)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if elapsed < tt.budget || elapsed > tt.budget+slack {
+				t.Errorf("returned after %v, want about %v", elapsed, tt.budget)
+			}
+			resp := decodeJSON(t, w)
+			if resp["stopped_by"] != "budget" {
+				t.Errorf("stopped_by = %v, want budget", resp["stopped_by"])
+			}
+			searched, _ := resp["searched_up_to"].(float64)
+			largest, _ := resp["largest_prime"].(float64)
+			if count, _ := resp["primes_count"].(float64); count <= 0 || largest <= 0 || largest > searched {
+				t.Errorf("primes_count = %v, largest_prime = %v, searched_up_to = %v", count, largest, searched)
+			}
+		})
+	}
+}
+
+func TestPrimesUntilStopsOnContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	searched, count, largest, stoppedBy := primesUntil(ctx, time.Now().Add(time.Hour))
+	if stoppedBy != "context" {
+		t.Errorf("stopped_by = %q, want context", stoppedBy)
+	}
+	if searched != primeSegmentSize+1 {
+		t.Errorf("searched up to %d, want one segment (%d)", searched, primeSegmentSize+1)
+	}
+	if primes := eratosthenesPrimes(context.Background(), searched); count != len(primes) || largest != primes[len(primes)-1] {
+		t.Errorf("count = %d, largest = %d, want %d and %d", count, largest, len(primes), primes[len(primes)-1])
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Most requests a single /selfbench call may make
+const maxSelfBenchRequests = 100000
+
+type SelfBenchRequest struct {
+	// Standard workload name, e.g. "reverse" or "cpu-intensive"
+	Target string `json:"target" binding:"required"`
+
+	// Fields merged over the workload's standard JSON body
+	Payload map[string]interface{} `json:"payload"`
+
+	// How many times to run the workload (default 100)
+	Requests int `json:"requests"`
+}
+
+// handleSelfBench runs one standard workload back to back in-process and
+// reports its latency distribution and throughput, as a baseline with no
+// network in the way.
+func handleSelfBench(handler http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SelfBenchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Requests == 0 {
+			req.Requests = 100
+		}
+		if req.Requests < 1 || req.Requests > maxSelfBenchRequests {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("requests must be between 1 and %d", maxSelfBenchRequests)})
+			return
+		}
+
+		w, ok := standardWorkload(req.Target)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown workload: " + req.Target})
+			return
+		}
+		if len(req.Payload) > 0 {
+			body, err := mergeWorkloadBody(w, req.Payload)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			w.Body = body
+		}
+
+		latencies := make([]time.Duration, 0, req.Requests)
+		errors := 0
+		start := time.Now()
+		for i := 0; i < req.Requests; i++ {
+			if c.Request.Context().Err() != nil {
+				break
+			}
+			status, elapsed := runWorkload(handler, w)
+			latencies = append(latencies, elapsed)
+			if status < 200 || status >= 300 {
+				errors++
+			}
+		}
+		elapsed := time.Since(start)
+
+		c.JSON(http.StatusOK, gin.H{
+			"target":              req.Target,
+			"requests":            len(latencies),
+			"errors":              errors,
+			"duration_seconds":    elapsed.Seconds(),
+			"requests_per_second": float64(len(latencies)) / elapsed.Seconds(),
+			"latency_ms":          latencySummary(latencies),
+			"service":             "Go Gin",
+		})
+	}
+}
+
+// mergeWorkloadBody overlays payload on w's JSON body, so a payload can
+// change the text of a string workload without repeating its operation.
+func mergeWorkloadBody(w workload, payload map[string]interface{}) (string, error) {
+	if w.Method != http.MethodPost {
+		return "", fmt.Errorf("workload %s takes no payload", w.Name)
+	}
+	fields := map[string]interface{}{}
+	if w.Body != "" {
+		if err := json.Unmarshal([]byte(w.Body), &fields); err != nil {
+			return "", err
+		}
+	}
+	for k, v := range payload {
+		fields[k] = v
+	}
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("invalid payload: %v", err)
+	}
+	return string(body), nil
+}
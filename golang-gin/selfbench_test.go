@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSelfBench(t *testing.T) {
+	r := gin.New()
+	r.POST("/selfbench", handleSelfBench(newReplayRouter()))
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		requests int
+	}{
+		{"reverse", `{"target":"reverse","requests":200}`, http.StatusOK, 200},
+		{"default request count", `{"target":"reverse"}`, http.StatusOK, 100},
+		{"reverse with a payload", `{"target":"reverse","payload":{"text":"héllo wörld"},"requests":50}`, http.StatusOK, 50},
+		{"above the cap", `{"target":"reverse","requests":100001}`, http.StatusBadRequest, 0},
+		{"unknown target", `{"target":"nope"}`, http.StatusBadRequest, 0},
+		{"missing target", `{"requests":10}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/selfbench", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			resp := decodeJSON(t, w)
+			if resp["requests"] != float64(tt.requests) || resp["errors"] != float64(0) {
+				t.Errorf("requests = %v, errors = %v, want %d and 0", resp["requests"], resp["errors"], tt.requests)
+			}
+			if rps, _ := resp["requests_per_second"].(float64); rps <= 0 {
+				t.Errorf("requests_per_second = %v, want a positive number", resp["requests_per_second"])
+			}
+
+			latency, _ := resp["latency_ms"].(map[string]interface{})
+			ordered := []string{"min", "p50", "p95", "p99", "max"}
+			for _, field := range append(ordered, "mean") {
+				if _, ok := latency[field].(float64); !ok {
+					t.Fatalf("latency_ms has no %s: %v", field, latency)
+				}
+			}
+			for i := 1; i < len(ordered); i++ {
+				if latency[ordered[i-1]].(float64) > latency[ordered[i]].(float64) {
+					t.Errorf("%s = %v is above %s = %v", ordered[i-1], latency[ordered[i-1]], ordered[i], latency[ordered[i]])
+				}
+			}
+		})
+	}
+}
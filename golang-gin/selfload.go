@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits for /selfload
+const (
+	maxSelfLoadConcurrency = 64
+	maxSelfLoadDuration    = 60
+)
+
+type SelfLoadRequest struct {
+	Endpoint string                 `json:"endpoint" binding:"required"`
+	Params   map[string]interface{} `json:"params"`
+
+	// Goroutines replaying the endpoint (default 1)
+	Concurrency int `json:"concurrency"`
+
+	// Seconds to run for (default 5); at most the -request-timeout
+	DurationS float64 `json:"duration_s"`
+}
+
+// loadReport summarises a closed-loop load run.
+type loadReport struct {
+	Requests          int     `json:"requests"`
+	Errors            int     `json:"errors"`
+	DurationSeconds   float64 `json:"duration_seconds"`
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	LatencyMs         gin.H   `json:"latency_ms"`
+}
+
+// handleSelfLoad drives one of the workloads in-process from C goroutines
+// for D seconds and reports throughput and latency percentiles.
+func handleSelfLoad(handler http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SelfLoadRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Concurrency == 0 {
+			req.Concurrency = 1
+		}
+		if req.Concurrency < 1 || req.Concurrency > maxSelfLoadConcurrency {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("concurrency must be between 1 and %d", maxSelfLoadConcurrency)})
+			return
+		}
+		if req.DurationS == 0 {
+			req.DurationS = 5
+		}
+		if err := checkLoadDuration(req.DurationS); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		w, err := selfLoadWorkload(req.Endpoint, req.Params)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		report := runLoad(c.Request.Context(), handler, w, req.Concurrency, time.Duration(req.DurationS*float64(time.Second)))
+		if timedOut(c) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"endpoint":    req.Endpoint,
+			"concurrency": req.Concurrency,
+			"report":      report,
+			"service":     "Go Gin",
+		})
+	}
+}
+
+// checkLoadDuration validates a duration_s for /selfload or /benchmark,
+// which must end within the request's deadline.
+func checkLoadDuration(seconds float64) error {
+	if !(seconds > 0 && seconds <= maxSelfLoadDuration) {
+		return fmt.Errorf("duration_s must be more than 0 and at most %d seconds, or 0 for the default", maxSelfLoadDuration)
+	}
+	if timeout := config.RequestTimeout; timeout > 0 && seconds > timeout.Seconds() {
+		return fmt.Errorf("duration_s must not exceed the request timeout of %g seconds", timeout.Seconds())
+	}
+	return nil
+}
+
+// selfLoadWorkload builds the request to replay. Params are sent as the
+// JSON body of POST endpoints; query parameters may be part of endpoint.
+func selfLoadWorkload(endpoint string, params map[string]interface{}) (workload, error) {
+	path, _, _ := strings.Cut(endpoint, "?")
+	if path == "/" {
+		return workload{Name: endpoint, Method: http.MethodGet, Path: endpoint}, nil
+	}
+	if !strings.HasPrefix(path, "/process/") {
+		return workload{}, fmt.Errorf("endpoint must be / or a /process/ endpoint")
+	}
+
+	body := "{}"
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return workload{}, fmt.Errorf("invalid params: %v", err)
+		}
+		body = string(data)
+	}
+	return workload{Name: endpoint, Method: http.MethodPost, Path: endpoint, Body: body}, nil
+}
+
+// runLoad replays w from concurrency goroutines until duration elapses or
+// ctx ends.
+func runLoad(ctx context.Context, handler http.Handler, w workload, concurrency int, duration time.Duration) loadReport {
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		errors    int
+		wg        sync.WaitGroup
+	)
+
+	start := time.Now()
+	deadline := start.Add(duration)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local []time.Duration
+			localErrors := 0
+			for time.Now().Before(deadline) && ctx.Err() == nil {
+				status, elapsed := runWorkload(handler, w)
+				local = append(local, elapsed)
+				if status < 200 || status >= 300 {
+					localErrors++
+				}
+			}
+
+			mu.Lock()
+			latencies = append(latencies, local...)
+			errors += localErrors
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	return loadReport{
+		Requests:          len(latencies),
+		Errors:            errors,
+		DurationSeconds:   elapsed.Seconds(),
+		RequestsPerSecond: float64(len(latencies)) / elapsed.Seconds(),
+		LatencyMs:         latencySummary(latencies),
+	}
+}
+
+// latencySummary reports min/mean/max and percentiles in milliseconds.
+func latencySummary(latencies []time.Duration) gin.H {
+	if len(latencies) == 0 {
+		return gin.H{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+	return gin.H{
+		"min":  ms(latencies[0]),
+		"mean": ms(total / time.Duration(len(latencies))),
+		"p50":  ms(percentile(latencies, 50)),
+		"p90":  ms(percentile(latencies, 90)),
+		"p95":  ms(percentile(latencies, 95)),
+		"p99":  ms(percentile(latencies, 99)),
+		"max":  ms(latencies[len(latencies)-1]),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(float64(len(sorted))*p/100+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
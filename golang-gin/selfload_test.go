@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkLoadReport checks a loadReport has requests, no errors, and
+// latency percentiles in order.
+func checkLoadReport(t *testing.T, report loadReport) {
+	t.Helper()
+	if report.Requests <= 0 || report.Errors != 0 {
+		t.Errorf("requests = %d, errors = %d, want some requests and no errors", report.Requests, report.Errors)
+	}
+	if report.RequestsPerSecond <= 0 {
+		t.Errorf("requests_per_second = %v, want a positive number", report.RequestsPerSecond)
+	}
+	ordered := []string{"min", "p50", "p90", "p95", "p99", "max"}
+	previous := 0.0
+	for _, field := range ordered {
+		value, ok := report.LatencyMs[field].(float64)
+		if !ok {
+			t.Fatalf("latency_ms has no %s: %v", field, report.LatencyMs)
+		}
+		if value < previous {
+			t.Errorf("latency_ms %s = %v is below the one before it, %v", field, value, previous)
+		}
+		previous = value
+	}
+}
+
+func TestSelfLoad(t *testing.T) {
+	setConfig(t, &config.RequestTimeout, 2*time.Second)
+	r := gin.New()
+	r.POST("/selfload", handleSelfLoad(newReplayRouter()))
+
+	tests := []struct {
+		name        string
+		body        string
+		status      int
+		concurrency int
+		duration    time.Duration
+	}{
+		{"hello", `{"endpoint":"/","duration_s":0.2}`, http.StatusOK, 1, 200 * time.Millisecond},
+		{"concurrent post", `{"endpoint":"/process/normal","params":{"name":"Ada Lovelace","birthdate":"1990-12-10","email":"ada@example.com"},"concurrency":4,"duration_s":0.2}`, http.StatusOK, 4, 200 * time.Millisecond},
+		{"query parameters", `{"endpoint":"/process/cpu-intensive?n=10","duration_s":0.1}`, http.StatusOK, 1, 100 * time.Millisecond},
+		{"negative duration", `{"endpoint":"/","duration_s":-1}`, http.StatusBadRequest, 0, 0},
+		{"duration past the request timeout", `{"endpoint":"/","duration_s":3}`, http.StatusBadRequest, 0, 0},
+		{"duration over the cap", `{"endpoint":"/","duration_s":61}`, http.StatusBadRequest, 0, 0},
+		{"too many goroutines", `{"endpoint":"/","concurrency":65,"duration_s":0.1}`, http.StatusBadRequest, 0, 0},
+		{"not a workload", `{"endpoint":"/metrics","duration_s":0.1}`, http.StatusBadRequest, 0, 0},
+		{"missing endpoint", `{"duration_s":0.1}`, http.StatusBadRequest, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/selfload", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Concurrency int        `json:"concurrency"`
+				Report      loadReport `json:"report"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Concurrency != tt.concurrency {
+				t.Errorf("concurrency = %d, want %d", resp.Concurrency, tt.concurrency)
+			}
+			if d := time.Duration(resp.Report.DurationSeconds * float64(time.Second)); d < tt.duration || d > tt.duration+time.Second {
+				t.Errorf("ran for %v, want about %v", d, tt.duration)
+			}
+			checkLoadReport(t, resp.Report)
+		})
+	}
+}
+
+func TestSelfLoadStopsAtTheDeadline(t *testing.T) {
+	r := gin.New()
+	r.POST("/selfload", timeoutRequests(100*time.Millisecond), handleSelfLoad(newReplayRouter()))
+
+	start := time.Now()
+	w := doRequest(r, http.MethodPost, "/selfload", `{"endpoint":"/","duration_s":5}`)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d: %s", w.Code, http.StatusServiceUnavailable, w.Body)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want it to stop at the 100ms deadline", elapsed)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SortRequest struct {
+	// Number of integers to sort (default 100000, capped by -max-sort-count)
+	Count int `json:"count"`
+
+	// Seed for the pseudo-random input (default -seed)
+	Seed *int64 `json:"seed"`
+}
+
+// handleSort sorts a seeded pseudo-random slice of ints with sort.Ints.
+// Only the sort itself is timed, not generating the input.
+func handleSort(c *gin.Context) {
+	var req SortRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Count == 0 {
+		req.Count = 100000
+	}
+	if req.Count < 1 || req.Count > config.MaxSortCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid count: must be between 1 and %d", config.MaxSortCount)})
+		return
+	}
+	seed := config.Seed
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	values := make([]int, req.Count)
+	for i := range values {
+		// 31-bit values stay exact in JSON clients that only have doubles
+		values[i] = int(rng.Int31())
+	}
+
+	startTime := time.Now()
+	cpuTimer := startCPUTimer(c)
+	sort.Ints(values)
+	endTime := time.Now()
+	setServerTimingTotal(c, endTime.Sub(startTime))
+
+	result := gin.H{
+		"count":                  req.Count,
+		"seed":                   seed,
+		"first":                  values[0],
+		"last":                   values[len(values)-1],
+		"execution_time_seconds": endTime.Sub(startTime).Seconds(),
+		"execution_time_ns":      endTime.Sub(startTime).Nanoseconds(),
+		"service":                "Go Gin",
+	}
+	cpuTimer.record(result)
+	reportGCDisabled(c, result)
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSort(t *testing.T) {
+	setConfig(t, &config.MaxSortCount, 1000)
+	r := gin.New()
+	r.POST("/process/sort", handleSort)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"small count", `{"count":100,"seed":7}`, http.StatusOK},
+		{"one value", `{"count":1}`, http.StatusOK},
+		{"at the cap", `{"count":1000}`, http.StatusOK},
+		{"above the cap", `{"count":1001}`, http.StatusBadRequest},
+		{"negative count", `{"count":-5}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/sort", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			resp := decodeJSON(t, w)
+			first, _ := resp["first"].(float64)
+			last, _ := resp["last"].(float64)
+			if first > last {
+				t.Errorf("first = %v > last = %v", first, last)
+			}
+			if _, ok := resp["execution_time_ns"].(float64); !ok {
+				t.Errorf("execution_time_ns = %v, want a duration", resp["execution_time_ns"])
+			}
+		})
+	}
+}
+
+func TestSortSeedIsReproducible(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/sort", handleSort)
+
+	sorted := func(body string) [2]interface{} {
+		resp := decodeJSON(t, doRequest(r, http.MethodPost, "/process/sort", body))
+		return [2]interface{}{resp["first"], resp["last"]}
+	}
+	first, second := sorted(`{"count":1000,"seed":42}`), sorted(`{"count":1000,"seed":42}`)
+	if first != second {
+		t.Errorf("seed 42 gave %v, then %v", first, second)
+	}
+	if other := sorted(`{"count":1000,"seed":43}`); other == first {
+		t.Errorf("seeds 42 and 43 both gave %v", first)
+	}
+}
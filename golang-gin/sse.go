@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const maxSSEEvents = 100000
+
+// handleSSE sends count server-sent events, interval_ms apart, each with
+// its sequence number and the time it was sent, then ends the response.
+// It stops early when the client goes away.
+func handleSSE(c *gin.Context) {
+	count, err := strconv.Atoi(c.DefaultQuery("count", "10"))
+	if err != nil || count < 1 || count > maxSSEEvents {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid count: must be between 1 and %d", maxSSEEvents)})
+		return
+	}
+	intervalMS, err := strconv.Atoi(c.DefaultQuery("interval_ms", "100"))
+	if err != nil || intervalMS < 0 || intervalMS > config.MaxDelayMS {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid interval_ms: must be between 0 and %d", config.MaxDelayMS)})
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(max(intervalMS, 1)) * time.Millisecond)
+	defer ticker.Stop()
+	for seq := 1; seq <= count; seq++ {
+		if seq > 1 && intervalMS > 0 {
+			select {
+			case <-ticker.C:
+			case <-c.Request.Context().Done():
+				return
+			}
+		} else if c.Request.Context().Err() != nil {
+			return
+		}
+
+		c.Render(-1, sse.Event{
+			Id:    strconv.Itoa(seq),
+			Event: "tick",
+			Data: gin.H{
+				"seq":          seq,
+				"timestamp_ns": time.Now().UnixNano(),
+			},
+		})
+		c.Writer.Flush()
+	}
+}
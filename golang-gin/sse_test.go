@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSSE(t *testing.T) {
+	r := gin.New()
+	r.Use(serverTimingHeader())
+	r.GET("/sse", handleSSE)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		events int
+	}{
+		{"five events", "?count=5&interval_ms=1", http.StatusOK, 5},
+		{"no interval", "?count=20&interval_ms=0", http.StatusOK, 20},
+		{"zero events", "?count=0", http.StatusBadRequest, 0},
+		{"negative interval", "?interval_ms=-1", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + "/sse" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+				t.Errorf("Content-Type = %s, want text/event-stream", contentType)
+			}
+
+			// Each event is id:, event: and data: lines followed by a blank line
+			seq := 0
+			var id string
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				line := scanner.Text()
+				switch {
+				case strings.HasPrefix(line, "id:"):
+					id = strings.TrimPrefix(line, "id:")
+				case strings.HasPrefix(line, "data:"):
+					var data struct {
+						Seq int `json:"seq"`
+					}
+					if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &data); err != nil {
+						t.Fatalf("bad data line %q: %v", line, err)
+					}
+					seq++
+					if data.Seq != seq || id != strconv.Itoa(seq) {
+						t.Fatalf("event %d has id %s and seq %d", seq, id, data.Seq)
+					}
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if seq != tt.events {
+				t.Errorf("received %d events, want %d", seq, tt.events)
+			}
+		})
+	}
+}
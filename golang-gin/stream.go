@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const streamChunkBytes = 32 << 10
+
+// streamChunk is the block written repeatedly by /stream, so every response
+// of a given size carries the same bytes.
+var streamChunk = func() []byte {
+	chunk := make([]byte, streamChunkBytes)
+	for i := range chunk {
+		chunk[i] = 'a' + byte(i%26)
+	}
+	return chunk
+}()
+
+// handleStream writes size_mb MB of deterministic data in chunks, flushing
+// after each one, so the response goes out with chunked transfer encoding
+// and the server never holds more than one chunk.
+func handleStream(c *gin.Context) {
+	sizeMB := 1
+	if v := c.Query("size_mb"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > config.MaxStreamMB {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid size_mb: must be between 1 and %d", config.MaxStreamMB)})
+			return
+		}
+		sizeMB = n
+	}
+
+	startTime := time.Now()
+	remaining := int64(sizeMB) << 20
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("X-Stream-Bytes", strconv.FormatInt(remaining, 10))
+	c.Stream(func(w io.Writer) bool {
+		chunk := streamChunk
+		if remaining < int64(len(chunk)) {
+			chunk = chunk[:remaining]
+		}
+		n, err := w.Write(chunk)
+		remaining -= int64(n)
+		return err == nil && remaining > 0
+	})
+	if remaining > 0 {
+		logf("warn", "stream: client went away with %d bytes unsent after %s", remaining, time.Since(startTime))
+	}
+}
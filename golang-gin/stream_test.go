@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStream(t *testing.T) {
+	setConfig(t, &config.MaxStreamMB, 4)
+	r := gin.New()
+	// The wrapping middleware mustn't hold the stream back
+	r.Use(serverTimingHeader(), compressResponses())
+	r.GET("/stream", handleStream)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		bytes  int64
+	}{
+		{"default size", "", http.StatusOK, 1 << 20},
+		{"at the cap", "?size_mb=4", http.StatusOK, 4 << 20},
+		{"above the cap", "?size_mb=5", http.StatusBadRequest, 0},
+		{"not a number", "?size_mb=big", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/stream"+tt.query, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			resp, err := http.DefaultTransport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			n, err := io.Copy(io.Discard, resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.bytes {
+				t.Errorf("read %d bytes, want %d", n, tt.bytes)
+			}
+			if header := resp.Header.Get("X-Stream-Bytes"); header != strconv.FormatInt(tt.bytes, 10) {
+				t.Errorf("X-Stream-Bytes = %s, want %d", header, tt.bytes)
+			}
+			if len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+				t.Errorf("Transfer-Encoding = %v, want chunked", resp.TransferEncoding)
+			}
+			if encoding := resp.Header.Get("Content-Encoding"); encoding != "" {
+				t.Errorf("Content-Encoding = %s, want none", encoding)
+			}
+		})
+	}
+}
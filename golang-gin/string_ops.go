@@ -0,0 +1,1135 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"hash/crc32"
+	"hash/crc64"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// luhnOperation validates Text as a Luhn-checksummed digit string and
+// reports the check digit that would make it valid if appended.
+func luhnOperation(text string, result gin.H) error {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(text)
+	if digits == "" {
+		return fmt.Errorf("Luhn input must contain at least one digit")
+	}
+	for _, ch := range digits {
+		if ch < '0' || ch > '9' {
+			return fmt.Errorf("Luhn input contains non-digit character: %q", ch)
+		}
+	}
+
+	result["digits"] = len(digits)
+	result["valid"] = luhnSum(digits, false)%10 == 0
+	result["check_digit"] = (10 - luhnSum(digits, true)%10) % 10
+	return nil
+}
+
+// luhnSum computes the Luhn sum of digits. When appendingCheck is true the
+// digits are treated as a payload that a check digit will be appended to,
+// which shifts which positions are doubled.
+func luhnSum(digits string, appendingCheck bool) int {
+	sum := 0
+	double := appendingCheck
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum
+}
+
+// csvColumn summarises one column of a csv_stats input.
+type csvColumn struct {
+	Name string   `json:"name"`
+	Type string   `json:"type"`
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+	Mean *float64 `json:"mean,omitempty"`
+}
+
+// csvStatsOperation parses Text as CSV with a header row and reports the
+// inferred type of every column plus min/max/mean for numeric columns.
+// Rows whose field count differs from the header are tolerated up to
+// tolerance; beyond that the input is rejected.
+func csvStatsOperation(text string, tolerance int, result gin.H) error {
+	if tolerance < 0 {
+		return fmt.Errorf("Invalid ragged_tolerance: must be 0 or more")
+	}
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("Malformed CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("Malformed CSV: no header row")
+	}
+
+	header := records[0]
+	rows := records[1:]
+
+	raggedRows := 0
+	for _, row := range rows {
+		if len(row) != len(header) {
+			raggedRows++
+		}
+	}
+	if raggedRows > tolerance {
+		return fmt.Errorf("Malformed CSV: %d ragged rows exceeds tolerance of %d", raggedRows, tolerance)
+	}
+
+	columns := make([]csvColumn, len(header))
+	for i, name := range header {
+		numeric := true
+		count := 0
+		sum, lo, hi := 0.0, math.Inf(1), math.Inf(-1)
+		for _, row := range rows {
+			if i >= len(row) || row[i] == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 64)
+			if err != nil {
+				numeric = false
+				break
+			}
+			count++
+			sum += value
+			lo = math.Min(lo, value)
+			hi = math.Max(hi, value)
+		}
+
+		columns[i] = csvColumn{Name: name, Type: "string"}
+		if numeric && count > 0 {
+			mean := sum / float64(count)
+			columns[i].Type = "numeric"
+			columns[i].Min = &lo
+			columns[i].Max = &hi
+			columns[i].Mean = &mean
+		}
+	}
+
+	result["row_count"] = len(rows)
+	result["column_count"] = len(header)
+	result["ragged_rows"] = raggedRows
+	result["columns"] = columns
+	return nil
+}
+
+// Dictionary used by wordbreak when the request doesn't supply one
+var defaultDictionary = []string{
+	"a", "an", "and", "apple", "at", "be", "bed", "bench", "benchmark", "cat",
+	"cats", "dog", "for", "go", "in", "is", "it", "mark", "of", "on", "pen",
+	"pine", "pineapple", "sand", "the", "to", "word", "words",
+}
+
+// Limits on wordbreak input. The search tries up to the longest word's
+// length of substrings at every byte of Text, so both bound the work.
+const (
+	maxWordBreakText   = 100000
+	maxDictionaryWords = 10000
+	maxDictionaryWord  = 64
+)
+
+// wordBreakOperation segments a space-free Text into dictionary words with
+// dynamic programming and reports one valid segmentation, if any.
+func wordBreakOperation(text string, dictionary []string, result gin.H) error {
+	if strings.ContainsAny(text, " \t\r\n") {
+		return fmt.Errorf("wordbreak input must not contain whitespace")
+	}
+	if len(text) > maxWordBreakText {
+		return fmt.Errorf("Text too long for wordbreak: must be at most %d bytes", maxWordBreakText)
+	}
+	if len(dictionary) > maxDictionaryWords {
+		return fmt.Errorf("Dictionary too large: must have at most %d words", maxDictionaryWords)
+	}
+	if len(dictionary) == 0 {
+		dictionary = defaultDictionary
+	}
+
+	words := make(map[string]bool, len(dictionary))
+	maxWordLen := 0
+	for _, word := range dictionary {
+		word = strings.ToLower(word)
+		if word == "" {
+			continue
+		}
+		if len(word) > maxDictionaryWord {
+			return fmt.Errorf("Dictionary word too long: must be at most %d bytes", maxDictionaryWord)
+		}
+		words[word] = true
+		if len(word) > maxWordLen {
+			maxWordLen = len(word)
+		}
+	}
+
+	// prev[i] is the start of the last word in a segmentation of text[:i],
+	// or -1 if text[:i] can't be segmented.
+	text = strings.ToLower(text)
+	prev := make([]int, len(text)+1)
+	for i := range prev {
+		prev[i] = -1
+	}
+	prev[0] = 0
+	for i := 1; i <= len(text); i++ {
+		for j := i - 1; j >= 0 && i-j <= maxWordLen; j-- {
+			if prev[j] != -1 && words[text[j:i]] {
+				prev[i] = j
+				break
+			}
+		}
+	}
+
+	result["dictionary_size"] = len(words)
+	if prev[len(text)] == -1 {
+		result["segmentable"] = false
+		result["segmentation"] = []string{}
+		return nil
+	}
+
+	var segmentation []string
+	for i := len(text); i > 0; i = prev[i] {
+		segmentation = append(segmentation, text[prev[i]:i])
+	}
+	for i, j := 0, len(segmentation)-1; i < j; i, j = i+1, j-1 {
+		segmentation[i], segmentation[j] = segmentation[j], segmentation[i]
+	}
+
+	result["segmentable"] = true
+	result["segmentation"] = segmentation
+	return nil
+}
+
+// Upper bound on the jaccard shingle length
+const maxShingleSize = 64
+
+// jaccardOperation computes the exact Jaccard similarity between the
+// character k-shingle sets of Text and Text2.
+func jaccardOperation(text, text2 string, k int, result gin.H) error {
+	if text2 == "" {
+		return fmt.Errorf("jaccard requires text2")
+	}
+	if k == 0 {
+		k = 3
+	}
+	if k < 1 || k > maxShingleSize {
+		return fmt.Errorf("Invalid shingle_size: must be between 1 and %d", maxShingleSize)
+	}
+
+	a := shingles(text, k)
+	b := shingles(text2, k)
+
+	intersection := 0
+	for shingle := range a {
+		if b[shingle] {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+
+	result["shingle_size"] = k
+	result["set_size_a"] = len(a)
+	result["set_size_b"] = len(b)
+	result["intersection"] = intersection
+	result["union"] = union
+	result["jaccard"] = float64(intersection) / float64(union)
+	return nil
+}
+
+// shingles returns the set of k-rune substrings of text. Texts shorter
+// than k form a single shingle.
+func shingles(text string, k int) map[string]bool {
+	runes := []rune(text)
+	set := make(map[string]bool)
+	if len(runes) < k {
+		set[text] = true
+		return set
+	}
+	for i := 0; i+k <= len(runes); i++ {
+		set[string(runes[i:i+k])] = true
+	}
+	return set
+}
+
+// Scripts recognised by script_detect
+var detectedScripts = []struct {
+	Name  string
+	Table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Greek", unicode.Greek},
+	{"Han", unicode.Han},
+	{"Hiragana", unicode.Hiragana},
+	{"Katakana", unicode.Katakana},
+	{"Hangul", unicode.Hangul},
+	{"Arabic", unicode.Arabic},
+	{"Hebrew", unicode.Hebrew},
+	{"Devanagari", unicode.Devanagari},
+	{"Thai", unicode.Thai},
+}
+
+// scriptDetectOperation classifies every rune of Text by Unicode script
+// and reports the dominant script and the share of each. Runes shared
+// between scripts (digits, punctuation, whitespace) are not counted.
+func scriptDetectOperation(text string, result gin.H) {
+	counts := make(map[string]int)
+	total := 0
+	for _, ch := range text {
+		if unicode.In(ch, unicode.Common, unicode.Inherited) {
+			continue
+		}
+		script := "Other"
+		for _, s := range detectedScripts {
+			if unicode.Is(s.Table, ch) {
+				script = s.Name
+				break
+			}
+		}
+		counts[script]++
+		total++
+	}
+
+	type scriptShare struct {
+		Script  string  `json:"script"`
+		Count   int     `json:"count"`
+		Percent float64 `json:"percent"`
+	}
+	breakdown := make([]scriptShare, 0, len(counts))
+	for script, count := range counts {
+		breakdown = append(breakdown, scriptShare{
+			Script:  script,
+			Count:   count,
+			Percent: float64(count) * 100 / float64(total),
+		})
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].Count != breakdown[j].Count {
+			return breakdown[i].Count > breakdown[j].Count
+		}
+		return breakdown[i].Script < breakdown[j].Script
+	})
+
+	dominant := "Unknown"
+	if len(breakdown) > 0 {
+		dominant = breakdown[0].Script
+	}
+
+	result["dominant_script"] = dominant
+	result["classified_runes"] = total
+	result["scripts"] = breakdown
+}
+
+// Maximum number of sorted words returned by multisort
+const maxSortedWords = 100
+
+// multiSortOperation stable-sorts the words of Text by length and then
+// alphabetically.
+func multiSortOperation(text string, result gin.H) {
+	words := strings.Fields(text)
+	sort.SliceStable(words, func(i, j int) bool {
+		li, lj := utf8.RuneCountInString(words[i]), utf8.RuneCountInString(words[j])
+		if li != lj {
+			return li < lj
+		}
+		return words[i] < words[j]
+	})
+
+	result["word_count"] = len(words)
+	if len(words) > maxSortedWords {
+		words = words[:maxSortedWords]
+	}
+	result["sorted"] = words
+}
+
+// Limits for spellcheck
+const (
+	maxSpellcheckTokens   = 1000
+	maxSpellcheckDistance = 5
+)
+
+// Dictionary used by spellcheck
+var spellDictionary = strings.Fields(`
+	a about after again all also an and any are as at back be because been
+	before being best between both brown but by can come could day did do
+	does dog down each even first for fox from get give go good great had has
+	have he her here him his how if in into is it its jump jumps just know
+	lazy like little long look made make many may me more most much must my
+	new no not now of off old on one only or other our out over people quick
+	right said same see she should show so some still such take than that
+	the their them then there these they thing think this those through time
+	to too two under up us use very want was way we well were what when where
+	which while who why will with word work world would year you your
+`)
+
+var spellDictionarySet = func() map[string]bool {
+	set := make(map[string]bool, len(spellDictionary))
+	for _, word := range spellDictionary {
+		set[word] = true
+	}
+	return set
+}()
+
+// spellSuggestion is the closest dictionary word for a misspelled token.
+type spellSuggestion struct {
+	Word       string `json:"word"`
+	Suggestion string `json:"suggestion"`
+	Distance   int    `json:"distance"`
+}
+
+// spellcheckOperation looks up every token of Text in the embedded
+// dictionary and suggests the closest word, by edit distance, for tokens
+// that aren't found.
+func spellcheckOperation(text string, maxDistance int, result gin.H) error {
+	if maxDistance == 0 {
+		maxDistance = 2
+	}
+	if maxDistance < 1 || maxDistance > maxSpellcheckDistance {
+		return fmt.Errorf("Invalid max_distance: must be between 1 and %d", maxSpellcheckDistance)
+	}
+
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	truncated := len(tokens) > maxSpellcheckTokens
+	if truncated {
+		tokens = tokens[:maxSpellcheckTokens]
+	}
+
+	suggestions := []spellSuggestion{}
+	unknown := 0
+	for _, token := range tokens {
+		if spellDictionarySet[token] {
+			continue
+		}
+		unknown++
+
+		best, bestDistance := "", maxDistance+1
+		for _, word := range spellDictionary {
+			if d := levenshtein(token, word); d < bestDistance {
+				best, bestDistance = word, d
+			}
+		}
+		if best != "" {
+			suggestions = append(suggestions, spellSuggestion{Word: token, Suggestion: best, Distance: bestDistance})
+		}
+	}
+
+	result["tokens_checked"] = len(tokens)
+	result["tokens_truncated"] = truncated
+	result["unknown_tokens"] = unknown
+	result["suggestions"] = suggestions
+	return nil
+}
+
+// levenshtein returns the edit distance between a and b, counted in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// readabilityOperation computes the Flesch Reading Ease and
+// Flesch-Kincaid grade level of Text.
+func readabilityOperation(text string, result gin.H) error {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) == 0 {
+		return fmt.Errorf("readability requires at least one word")
+	}
+
+	// A sentence ends at each run of terminal punctuation
+	sentences := 0
+	inTerminator := false
+	for _, ch := range text {
+		isTerminator := ch == '.' || ch == '!' || ch == '?'
+		if isTerminator && !inTerminator {
+			sentences++
+		}
+		inTerminator = isTerminator
+	}
+	if sentences == 0 {
+		sentences = 1
+	}
+
+	syllables := 0
+	for _, word := range words {
+		syllables += countSyllables(word)
+	}
+
+	wordsPerSentence := float64(len(words)) / float64(sentences)
+	syllablesPerWord := float64(syllables) / float64(len(words))
+
+	result["sentences"] = sentences
+	result["words"] = len(words)
+	result["syllables"] = syllables
+	result["flesch_reading_ease"] = 206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord
+	result["flesch_kincaid_grade"] = 0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59
+	return nil
+}
+
+// countSyllables estimates the syllables in word by counting vowel
+// groups, discounting a silent trailing "e". Every word has at least one.
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	isVowel := func(b byte) bool { return strings.IndexByte("aeiouy", b) >= 0 }
+
+	count := 0
+	prevVowel := false
+	for i := 0; i < len(word); i++ {
+		vowel := isVowel(word[i])
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+
+	if n := len(word); n > 2 && word[n-1] == 'e' && !strings.HasSuffix(word, "le") && !isVowel(word[n-2]) {
+		count--
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
+
+// Limits for fuzzy_match
+const (
+	maxFuzzyWords   = 10000
+	maxFuzzyMatches = 100
+)
+
+// fuzzyMatch is a word of Text within the allowed edit distance of Pattern.
+type fuzzyMatch struct {
+	Word     string `json:"word"`
+	Index    int    `json:"index"`
+	Distance int    `json:"distance"`
+}
+
+// fuzzyMatchOperation finds the words of Text within MaxDistance edits of
+// Pattern, ignoring case.
+func fuzzyMatchOperation(text, pattern string, maxDistance int, result gin.H) error {
+	if pattern == "" {
+		return fmt.Errorf("fuzzy_match requires pattern")
+	}
+	if maxDistance == 0 {
+		maxDistance = 2
+	}
+	if maxDistance < 1 || maxDistance > maxSpellcheckDistance {
+		return fmt.Errorf("Invalid max_distance: must be between 1 and %d", maxSpellcheckDistance)
+	}
+
+	words := strings.Fields(text)
+	truncated := len(words) > maxFuzzyWords
+	if truncated {
+		words = words[:maxFuzzyWords]
+	}
+
+	target := []rune(strings.ToLower(pattern))
+	matches := []fuzzyMatch{}
+	matchCount := 0
+	for i, word := range words {
+		distance, ok := boundedLevenshtein([]rune(strings.ToLower(word)), target, maxDistance)
+		if !ok {
+			continue
+		}
+		matchCount++
+		if len(matches) < maxFuzzyMatches {
+			matches = append(matches, fuzzyMatch{Word: word, Index: i, Distance: distance})
+		}
+	}
+
+	result["pattern"] = pattern
+	result["max_distance"] = maxDistance
+	result["words_checked"] = len(words)
+	result["words_truncated"] = truncated
+	result["match_count"] = matchCount
+	result["matches"] = matches
+	return nil
+}
+
+// boundedLevenshtein computes the edit distance between a and b, giving
+// up as soon as every cell of a DP row exceeds limit. This is equivalent
+// to running a Levenshtein automaton for b with limit errors over a.
+func boundedLevenshtein(a, b []rune, limit int) (int, bool) {
+	if diff := len(a) - len(b); diff > limit || -diff > limit {
+		return 0, false
+	}
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return 0, false
+		}
+		prev, curr = curr, prev
+	}
+
+	if prev[len(b)] > limit {
+		return 0, false
+	}
+	return prev[len(b)], true
+}
+
+// runsOperation splits Text into maximal strictly ascending or strictly
+// descending runs of codepoints. Equal neighbours end a run, and a rune
+// that can't extend either direction forms a run of length one.
+func runsOperation(text string, result gin.H) {
+	runes := []rune(text)
+	runs, ascending, descending, longest := 0, 0, 0, 0
+
+	for i := 0; i < len(runes); {
+		j := i
+		if i+1 < len(runes) && runes[i+1] != runes[i] {
+			up := runes[i+1] > runes[i]
+			for j+1 < len(runes) && runes[j+1] != runes[j] && (runes[j+1] > runes[j]) == up {
+				j++
+			}
+			if up {
+				ascending++
+			} else {
+				descending++
+			}
+		}
+
+		runs++
+		longest = max(longest, j-i+1)
+		i = j + 1
+	}
+
+	result["run_count"] = runs
+	result["ascending_runs"] = ascending
+	result["descending_runs"] = descending
+	result["longest_run"] = longest
+}
+
+// balanceOperation checks that the (), [] and {} brackets in Text are
+// balanced and properly nested. When they aren't, imbalance_position is
+// the rune index of the first unmatched closing bracket or, if every
+// closer matched, of the earliest bracket left open.
+func balanceOperation(text string, result gin.H) {
+	pairs := map[rune]rune{')': '(', ']': '[', '}': '{'}
+
+	type opener struct {
+		ch  rune
+		pos int
+	}
+	var stack []opener
+	maxDepth := 0
+	position := -1
+
+	pos := 0
+	for _, ch := range text {
+		switch ch {
+		case '(', '[', '{':
+			stack = append(stack, opener{ch, pos})
+			maxDepth = max(maxDepth, len(stack))
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1].ch != pairs[ch] {
+				position = pos
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		if position != -1 {
+			break
+		}
+		pos++
+	}
+	if position == -1 && len(stack) > 0 {
+		position = stack[0].pos
+	}
+
+	var imbalance interface{}
+	if position != -1 {
+		imbalance = position
+	}
+
+	result["balanced"] = position == -1
+	result["max_depth"] = maxDepth
+	result["imbalance_position"] = imbalance
+}
+
+// simHashOperation computes the 64-bit SimHash fingerprint of the word
+// shingles of Text and, when Text2 is given, its Hamming distance from
+// the fingerprint of Text2.
+func simHashOperation(text, text2 string, k int, result gin.H) error {
+	if k == 0 {
+		k = 2
+	}
+	if k < 1 || k > maxShingleSize {
+		return fmt.Errorf("Invalid shingle_size: must be between 1 and %d", maxShingleSize)
+	}
+
+	fingerprint := simHash(text, k)
+	result["shingle_size"] = k
+	result["fingerprint"] = fmt.Sprintf("%016x", fingerprint)
+
+	if text2 != "" {
+		fingerprint2 := simHash(text2, k)
+		result["fingerprint2"] = fmt.Sprintf("%016x", fingerprint2)
+		result["hamming_distance"] = bits.OnesCount64(fingerprint ^ fingerprint2)
+	}
+	return nil
+}
+
+// simHash sums the FNV-1a hashes of every k-word shingle bit by bit
+// (+1 for a set bit, -1 for a clear one) and keeps the sign of each sum.
+func simHash(text string, k int) uint64 {
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) < k {
+		k = max(len(words), 1)
+	}
+
+	var weights [64]int
+	h := fnv.New64a()
+	for i := 0; i+k <= len(words); i++ {
+		h.Reset()
+		h.Write([]byte(strings.Join(words[i:i+k], " ")))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint
+}
+
+// Limits for tfidf output
+const (
+	maxTfidfDocuments = 100
+	tfidfTopTerms     = 5
+)
+
+var blankLines = regexp.MustCompile(`\n\s*\n`)
+
+// termScore is a term and its tf-idf score within one document.
+type termScore struct {
+	Term  string  `json:"term"`
+	Score float64 `json:"score"`
+}
+
+// tfidfOperation splits Text into documents and reports the highest
+// scoring tf-idf terms of each. idf is smoothed as ln((1+N)/(1+df)) + 1
+// so terms present in every document still score above zero.
+func tfidfOperation(text, delimiter string, result gin.H) {
+	var rawDocs []string
+	if delimiter == "" {
+		rawDocs = blankLines.Split(text, -1)
+	} else {
+		rawDocs = strings.Split(text, delimiter)
+	}
+
+	var docs [][]string
+	for _, doc := range rawDocs {
+		terms := strings.FieldsFunc(strings.ToLower(doc), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(terms) > 0 {
+			docs = append(docs, terms)
+		}
+	}
+
+	// Document frequency of every term
+	df := make(map[string]int)
+	termCounts := make([]map[string]int, len(docs))
+	for i, terms := range docs {
+		counts := make(map[string]int)
+		for _, term := range terms {
+			counts[term]++
+		}
+		for term := range counts {
+			df[term]++
+		}
+		termCounts[i] = counts
+	}
+
+	n := float64(len(docs))
+	topTerms := [][]termScore{}
+	for i, counts := range termCounts {
+		if i == maxTfidfDocuments {
+			break
+		}
+		scores := make([]termScore, 0, len(counts))
+		for term, count := range counts {
+			tf := float64(count) / float64(len(docs[i]))
+			idf := math.Log((1+n)/(1+float64(df[term]))) + 1
+			scores = append(scores, termScore{Term: term, Score: tf * idf})
+		}
+		sort.Slice(scores, func(a, b int) bool {
+			if scores[a].Score != scores[b].Score {
+				return scores[a].Score > scores[b].Score
+			}
+			return scores[a].Term < scores[b].Term
+		})
+		if len(scores) > tfidfTopTerms {
+			scores = scores[:tfidfTopTerms]
+		}
+		topTerms = append(topTerms, scores)
+	}
+
+	result["document_count"] = len(docs)
+	result["vocabulary_size"] = len(df)
+	result["top_terms"] = topTerms
+}
+
+// Longest Text accepted by suffix_automaton, in runes
+const maxSuffixAutomatonRunes = 200000
+
+// samState is a suffix automaton state: the equivalence class of
+// substrings that end at the same set of positions.
+type samState struct {
+	length int
+	link   *samState
+	next   map[rune]*samState
+}
+
+// suffixAutomatonOperation builds the suffix automaton of Text online,
+// one rune at a time, and reports its size.
+func suffixAutomatonOperation(text string, result gin.H) error {
+	runes := []rune(text)
+	if len(runes) > maxSuffixAutomatonRunes {
+		return fmt.Errorf("Text too long for suffix_automaton: must be at most %d characters", maxSuffixAutomatonRunes)
+	}
+
+	buildStart := time.Now()
+	root := &samState{next: map[rune]*samState{}}
+	states := []*samState{root}
+	last := root
+	for _, r := range runes {
+		cur := &samState{length: last.length + 1, next: map[rune]*samState{}}
+		states = append(states, cur)
+
+		p := last
+		for p != nil && p.next[r] == nil {
+			p.next[r] = cur
+			p = p.link
+		}
+		switch {
+		case p == nil:
+			cur.link = root
+		case p.next[r].length == p.length+1:
+			cur.link = p.next[r]
+		default:
+			q := p.next[r]
+			clone := &samState{length: p.length + 1, link: q.link, next: make(map[rune]*samState, len(q.next))}
+			for key, target := range q.next {
+				clone.next[key] = target
+			}
+			states = append(states, clone)
+			for p != nil && p.next[r] == q {
+				p.next[r] = clone
+				p = p.link
+			}
+			q.link = clone
+			cur.link = clone
+		}
+		last = cur
+	}
+	buildTime := time.Since(buildStart)
+
+	transitions := 0
+	// Each state adds the substrings longer than its suffix link's
+	distinct := 0
+	for _, state := range states {
+		transitions += len(state.next)
+		if state.link != nil {
+			distinct += state.length - state.link.length
+		}
+	}
+
+	result["rune_count"] = len(runes)
+	result["states"] = len(states)
+	result["transitions"] = transitions
+	result["distinct_substrings"] = distinct
+	result["build_time_seconds"] = buildTime.Seconds()
+	return nil
+}
+
+// Match positions returned by boyermoore; match_count covers every match
+const maxBoyerMooreMatches = 1000
+
+// boyerMooreOperation finds every occurrence of Pattern in Text using the
+// bad-character and strong good-suffix rules. It works on bytes, so
+// positions are byte offsets into Text.
+func boyerMooreOperation(text, pattern string, result gin.H) error {
+	if pattern == "" {
+		return fmt.Errorf("boyermoore requires pattern")
+	}
+
+	preprocessStart := time.Now()
+	m := len(pattern)
+	var last [256]int
+	for i := range last {
+		last[i] = -1
+	}
+	for i := 0; i < m; i++ {
+		last[pattern[i]] = i
+	}
+	shift := goodSuffixShifts(pattern)
+	preprocessTime := time.Since(preprocessStart)
+
+	searchStart := time.Now()
+	positions := []int{}
+	matchCount, comparisons := 0, 0
+	for s := 0; s <= len(text)-m; {
+		j := m - 1
+		for j >= 0 {
+			comparisons++
+			if pattern[j] != text[s+j] {
+				break
+			}
+			j--
+		}
+		if j < 0 {
+			matchCount++
+			if len(positions) < maxBoyerMooreMatches {
+				positions = append(positions, s)
+			}
+			s += shift[0]
+			continue
+		}
+		s += max(shift[j+1], j-last[text[s+j]])
+	}
+	searchTime := time.Since(searchStart)
+
+	result["pattern"] = pattern
+	result["match_count"] = matchCount
+	result["positions"] = positions
+	result["positions_truncated"] = matchCount > len(positions)
+	result["comparisons"] = comparisons
+	result["preprocessing_time_seconds"] = preprocessTime.Seconds()
+	result["search_time_seconds"] = searchTime.Seconds()
+	return nil
+}
+
+// goodSuffixShifts builds the strong good-suffix table: shift[j+1] is how
+// far to move the pattern after a mismatch at j, and shift[0] the move
+// after a full match.
+func goodSuffixShifts(pattern string) []int {
+	m := len(pattern)
+	shift := make([]int, m+1)
+	// border[i] is where the widest border of pattern[i:] starts
+	border := make([]int, m+1)
+
+	i, j := m, m+1
+	border[i] = j
+	for i > 0 {
+		for j <= m && pattern[i-1] != pattern[j-1] {
+			if shift[j] == 0 {
+				shift[j] = j - i
+			}
+			j = border[j]
+		}
+		i--
+		j--
+		border[i] = j
+	}
+
+	j = border[0]
+	for i := 0; i <= m; i++ {
+		if shift[i] == 0 {
+			shift[i] = j
+		}
+		if i == j {
+			j = border[j]
+		}
+	}
+	return shift
+}
+
+// Limits for regex
+const (
+	maxRegexPatternLength = 1000
+	maxRegexMatches       = 10
+)
+
+// regexMatch is one match of the regex operation.
+type regexMatch struct {
+	Match  string `json:"match"`
+	Offset int    `json:"offset"`
+}
+
+// regexOperation counts the matches of Pattern in Text and returns the
+// first few with their byte offsets. Go's RE2 engine matches in linear
+// time, so only the pattern's length needs bounding.
+func regexOperation(text, pattern string, result gin.H) error {
+	if pattern == "" {
+		return fmt.Errorf("regex requires pattern")
+	}
+	if len(pattern) > maxRegexPatternLength {
+		return fmt.Errorf("Pattern too long: must be at most %d bytes", maxRegexPatternLength)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("Invalid pattern: %v", err)
+	}
+
+	locations := re.FindAllStringIndex(text, -1)
+	matches := []regexMatch{}
+	for _, loc := range locations {
+		if len(matches) == maxRegexMatches {
+			break
+		}
+		matches = append(matches, regexMatch{Match: text[loc[0]:loc[1]], Offset: loc[0]})
+	}
+
+	result["pattern"] = pattern
+	result["match_count"] = len(locations)
+	result["matches"] = matches
+	return nil
+}
+
+// base64Operation encodes Text with standard base64 and decodes it again,
+// timing each direction and checking the round trip is lossless.
+func base64Operation(text string, result gin.H) {
+	encodeStart := time.Now()
+	encoded := base64.StdEncoding.EncodeToString([]byte(text))
+	decodeStart := time.Now()
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	decodeTime := time.Since(decodeStart)
+
+	result["encoded_length"] = len(encoded)
+	result["sample"] = sample(encoded)
+	result["roundtrip_ok"] = err == nil && string(decoded) == text
+	result["encode_time_seconds"] = decodeStart.Sub(encodeStart).Seconds()
+	result["decode_time_seconds"] = decodeTime.Seconds()
+}
+
+// checksumOperation computes a CRC of Text's bytes. variant is one of
+// crc32 (IEEE, the default), crc32c (Castagnoli), crc64 (ISO) or crc64-ecma.
+func checksumOperation(text, variant string, result gin.H) error {
+	if variant == "" {
+		variant = "crc32"
+	}
+
+	data := []byte(text)
+	start := time.Now()
+	var digest string
+	switch variant {
+	case "crc32":
+		digest = fmt.Sprintf("%08x", crc32.ChecksumIEEE(data))
+	case "crc32c":
+		digest = fmt.Sprintf("%08x", crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)))
+	case "crc64":
+		digest = fmt.Sprintf("%016x", crc64.Checksum(data, crc64.MakeTable(crc64.ISO)))
+	case "crc64-ecma":
+		digest = fmt.Sprintf("%016x", crc64.Checksum(data, crc64.MakeTable(crc64.ECMA)))
+	default:
+		return fmt.Errorf("Invalid variant: must be crc32, crc32c, crc64 or crc64-ecma")
+	}
+
+	result["variant"] = variant
+	result["checksum"] = digest
+	result["checksum_time_seconds"] = time.Since(start).Seconds()
+	return nil
+}
+
+// titlecaseOperation title-cases Text with x/text's Unicode word-boundary
+// and casing rules rather than splitting on spaces.
+func titlecaseOperation(text string, result gin.H) {
+	processed := cases.Title(language.Und).String(text)
+	result["processed_length"] = len(processed)
+	result["sample"] = sample(processed)
+}
+
+// rot13Operation rotates ASCII letters by 13 places and leaves every other
+// rune, including non-ASCII letters, as it was.
+func rot13Operation(text string, result gin.H) {
+	processed := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			return 'A' + (r-'A'+13)%26
+		}
+		return r
+	}, text)
+	result["processed_length"] = len(processed)
+	result["sample"] = sample(processed)
+}
+
+// palindromeOperation reports whether Text reads the same backwards,
+// comparing runes case-insensitively and skipping whitespace.
+func palindromeOperation(text string, result gin.H) {
+	runes := make([]rune, 0, len(text))
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			runes = append(runes, unicode.ToLower(r))
+		}
+	}
+
+	isPalindrome := true
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		if runes[i] != runes[j] {
+			isPalindrome = false
+			break
+		}
+	}
+
+	normalized := string(runes)
+	result["is_palindrome"] = isPalindrome
+	result["compared_runes"] = len(runes)
+	result["sample"] = sample(normalized)
+}
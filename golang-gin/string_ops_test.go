@@ -0,0 +1,889 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMultiSortOperation(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"length then alphabetical", "banana kiwi fig apple", []string{"fig", "kiwi", "apple", "banana"}},
+		{"equal lengths", "dog cat ant bee cat ant", []string{"ant", "ant", "bee", "cat", "cat", "dog"}},
+		{"length counts runes", "ééé abcd ab", []string{"ab", "ééé", "abcd"}},
+		{"empty", "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			multiSortOperation(tt.text, result)
+			if got := result["sorted"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sorted = %q, want %q", got, tt.want)
+			}
+			if result["word_count"] != len(tt.want) {
+				t.Errorf("word_count = %v, want %d", result["word_count"], len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMultiSortOperationBoundsList(t *testing.T) {
+	result := gin.H{}
+	multiSortOperation(strings.Repeat("word ", maxSortedWords+50), result)
+	if n := len(result["sorted"].([]string)); n != maxSortedWords {
+		t.Errorf("len(sorted) = %d, want %d", n, maxSortedWords)
+	}
+	if result["word_count"] != maxSortedWords+50 {
+		t.Errorf("word_count = %v, want %d", result["word_count"], maxSortedWords+50)
+	}
+}
+
+func TestRegexOperation(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		pattern string
+		count   int
+		matches []regexMatch
+		wantErr bool
+	}{
+		{"matches", "cat hat bat", `[ch]at`, 2, []regexMatch{{Match: "cat", Offset: 0}, {Match: "hat", Offset: 4}}, false},
+		{"no matches", "cat hat bat", `dog`, 0, []regexMatch{}, false},
+		{"invalid pattern", "cat hat bat", `(unclosed`, 0, nil, true},
+		{"missing pattern", "cat hat bat", "", 0, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := regexOperation(tt.text, tt.pattern, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result["match_count"] != tt.count {
+				t.Errorf("match_count = %v, want %d", result["match_count"], tt.count)
+			}
+			if !reflect.DeepEqual(result["matches"], tt.matches) {
+				t.Errorf("matches = %v, want %v", result["matches"], tt.matches)
+			}
+		})
+	}
+}
+
+func TestBase64Operation(t *testing.T) {
+	tests := []struct {
+		text    string
+		encoded int
+		sample  string
+	}{
+		{"Hello, World!", 20, "SGVsbG8sIFdvcmxkIQ=="},
+		{"", 0, ""},
+		{"ab", 4, "YWI="},
+		{"héllo 🌍", 16, "aMOpbGxvIPCfjI0="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result := gin.H{}
+			base64Operation(tt.text, result)
+			if result["encoded_length"] != tt.encoded {
+				t.Errorf("encoded_length = %v, want %d", result["encoded_length"], tt.encoded)
+			}
+			if result["sample"] != tt.sample {
+				t.Errorf("sample = %v, want %q", result["sample"], tt.sample)
+			}
+			if result["roundtrip_ok"] != true {
+				t.Error("roundtrip_ok is not true")
+			}
+		})
+	}
+}
+
+func TestChecksumOperation(t *testing.T) {
+	// The standard check values for each variant's "123456789"
+	tests := []struct {
+		text     string
+		variant  string
+		checksum string
+		wantErr  bool
+	}{
+		{"123456789", "", "cbf43926", false},
+		{"123456789", "crc32", "cbf43926", false},
+		{"123456789", "crc32c", "e3069283", false},
+		{"123456789", "crc64", "b90956c775a41001", false},
+		{"123456789", "crc64-ecma", "995dc9bbdf1939fa", false},
+		{"The quick brown fox jumps over the lazy dog", "crc32", "414fa339", false},
+		{"", "crc32", "00000000", false},
+		{"123456789", "md5", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.variant+"/"+tt.text, func(t *testing.T) {
+			result := gin.H{}
+			err := checksumOperation(tt.text, tt.variant, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && result["checksum"] != tt.checksum {
+				t.Errorf("checksum = %v, want %s", result["checksum"], tt.checksum)
+			}
+		})
+	}
+}
+
+func TestTitlecaseOperation(t *testing.T) {
+	tests := []struct {
+		text   string
+		sample string
+	}{
+		{"hELLO wORLD", "Hello World"},
+		{"the QUICK brown-fox", "The Quick Brown-Fox"},
+		{"élan VITAL", "Élan Vital"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result := gin.H{}
+			titlecaseOperation(tt.text, result)
+			if result["sample"] != tt.sample {
+				t.Errorf("sample = %v, want %q", result["sample"], tt.sample)
+			}
+			if result["processed_length"] != len(tt.sample) {
+				t.Errorf("processed_length = %v, want %d", result["processed_length"], len(tt.sample))
+			}
+		})
+	}
+}
+
+func TestRot13Operation(t *testing.T) {
+	tests := []struct {
+		text   string
+		sample string
+	}{
+		{"Hello, World!", "Uryyb, Jbeyq!"},
+		{"Uryyb, Jbeyq!", "Hello, World!"},
+		{"nopqrstuvwxyzabcdefghijklm", "abcdefghijklmnopqrstuvwxyz"},
+		{"héllo 🌍", "uéyyb 🌍"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result := gin.H{}
+			rot13Operation(tt.text, result)
+			if result["sample"] != tt.sample {
+				t.Errorf("sample = %v, want %q", result["sample"], tt.sample)
+			}
+		})
+	}
+}
+
+func TestPalindromeOperation(t *testing.T) {
+	tests := []struct {
+		text         string
+		isPalindrome bool
+		runes        int
+	}{
+		{"racecar", true, 7},
+		{"Never odd or even", true, 14},
+		{"А роза упала на лапу Азора", true, 21},
+		{"日本日", true, 3},
+		{"éé", true, 2},
+		{"éè", false, 2},
+		{"palindrome", false, 10},
+		{"", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result := gin.H{}
+			palindromeOperation(tt.text, result)
+			if result["is_palindrome"] != tt.isPalindrome {
+				t.Errorf("is_palindrome = %v, want %v", result["is_palindrome"], tt.isPalindrome)
+			}
+			if result["compared_runes"] != tt.runes {
+				t.Errorf("compared_runes = %v, want %d", result["compared_runes"], tt.runes)
+			}
+		})
+	}
+}
+
+func TestWordBreakOperation(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		dictionary   []string
+		segmentation []string
+		wantErr      bool
+	}{
+		{"default dictionary", "gotothebench", nil, []string{"go", "to", "the", "bench"}, false},
+		{"case-insensitive", "GoIsFun", []string{"go", "is", "fun"}, []string{"go", "is", "fun"}, false},
+		{"not segmentable", "xyz", nil, []string{}, false},
+		{"whitespace", "go is", nil, nil, true},
+		{"text at the limit", strings.Repeat("a", maxWordBreakText), []string{"a"}, nil, false},
+		{"text over the limit", strings.Repeat("a", maxWordBreakText+1), []string{"a"}, nil, true},
+		{"dictionary over the limit", "a", make([]string, maxDictionaryWords+1), nil, true},
+		{"word at the limit", strings.Repeat("b", maxDictionaryWord), []string{strings.Repeat("b", maxDictionaryWord)}, []string{strings.Repeat("b", maxDictionaryWord)}, false},
+		{"word over the limit", "b", []string{strings.Repeat("b", maxDictionaryWord+1)}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := wordBreakOperation(tt.text, tt.dictionary, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.segmentation != nil && !reflect.DeepEqual(result["segmentation"], tt.segmentation) {
+				t.Errorf("segmentation = %v, want %v", result["segmentation"], tt.segmentation)
+			}
+		})
+	}
+}
+
+func TestWordBreakLimitsReturn400(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/strings", handleStringProcessing)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"text", fmt.Sprintf(`{"operation":"wordbreak","text":%q}`, strings.Repeat("a", maxWordBreakText+1))},
+		{"dictionary", fmt.Sprintf(`{"operation":"wordbreak","text":"a","dictionary":[%s"a"]}`, strings.Repeat(`"a",`, maxDictionaryWords))},
+		{"word", fmt.Sprintf(`{"operation":"wordbreak","text":"a","dictionary":[%q]}`, strings.Repeat("a", maxDictionaryWord+1))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doRequest(r, http.MethodPost, "/process/strings", tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+		})
+	}
+}
+
+func TestLuhnOperation(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantErr    bool
+		valid      bool
+		checkDigit int
+	}{
+		{"valid number", "79927398713", false, true, 8},
+		{"payload", "7992739871", false, false, 3},
+		{"separators", "4111 1111-1111 1111", false, true, 3},
+		{"single zero", "0", false, true, 0},
+		{"letters", "7992a", true, false, 0},
+		{"only separators", " - ", true, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := luhnOperation(tt.text, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result["valid"] != tt.valid {
+				t.Errorf("valid = %v, want %v", result["valid"], tt.valid)
+			}
+			if result["check_digit"] != tt.checkDigit {
+				t.Errorf("check_digit = %v, want %d", result["check_digit"], tt.checkDigit)
+			}
+		})
+	}
+}
+
+func TestJaccardOperation(t *testing.T) {
+	tests := []struct {
+		name         string
+		text, text2  string
+		k            int
+		wantErr      bool
+		intersection int
+		union        int
+	}{
+		{"identical", "abcd", "abcd", 3, false, 2, 2},
+		{"overlapping", "abcd", "bcde", 3, false, 1, 3},
+		{"disjoint", "abc", "xyz", 0, false, 0, 2},
+		{"repeated shingles", "aaaa", "aa", 2, false, 1, 1},
+		{"shorter than k", "ab", "ab", 5, false, 1, 1},
+		{"shingles are runes", "héllo", "hello", 2, false, 2, 6},
+		{"missing text2", "abc", "", 3, true, 0, 0},
+		{"negative k", "abc", "abc", -1, true, 0, 0},
+		{"k too large", "abc", "abc", maxShingleSize + 1, true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := jaccardOperation(tt.text, tt.text2, tt.k, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result["intersection"] != tt.intersection || result["union"] != tt.union {
+				t.Errorf("intersection = %v, union = %v, want %d and %d", result["intersection"], result["union"], tt.intersection, tt.union)
+			}
+			if want := float64(tt.intersection) / float64(tt.union); result["jaccard"] != want {
+				t.Errorf("jaccard = %v, want %v", result["jaccard"], want)
+			}
+		})
+	}
+}
+
+func TestScriptDetect(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/strings", handleStringProcessing)
+
+	tests := []struct {
+		name       string
+		body       string
+		status     int
+		dominant   string
+		classified float64
+	}{
+		{"latin", `{"operation":"script_detect","text":"Hello, world! 123"}`, http.StatusOK, "Latin", 10},
+		{"cyrillic", `{"operation":"script_detect","text":"Привет, мир"}`, http.StatusOK, "Cyrillic", 9},
+		{"mixed", `{"operation":"script_detect","text":"你好世界 hi"}`, http.StatusOK, "Han", 6},
+		{"tie breaks by name", `{"operation":"script_detect","text":"ab αβ"}`, http.StatusOK, "Greek", 4},
+		{"unlisted script", `{"operation":"script_detect","text":"ᚠᚢᚦ"}`, http.StatusOK, "Other", 3},
+		{"no letters", `{"operation":"script_detect","text":"123 !?"}`, http.StatusOK, "Unknown", 0},
+		{"text not a string", `{"operation":"script_detect","text":42}`, http.StatusBadRequest, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/strings", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			resp := decodeJSON(t, w)
+			if resp["dominant_script"] != tt.dominant || resp["classified_runes"] != tt.classified {
+				t.Errorf("dominant_script = %v, classified_runes = %v, want %s and %v", resp["dominant_script"], resp["classified_runes"], tt.dominant, tt.classified)
+			}
+		})
+	}
+}
+
+func TestSpellcheckOperation(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		maxDistance int
+		wantErr     bool
+		checked     int
+		unknown     int
+		suggestions []spellSuggestion
+	}{
+		{"all known", "The quick brown fox", 0, false, 4, 0, []spellSuggestion{}},
+		{"misspellings", "the brwn fox, lazzy DGO!", 0, false, 5, 3, []spellSuggestion{
+			{"brwn", "brown", 1}, {"lazzy", "lazy", 1}, {"dgo", "do", 1},
+		}},
+		{"beyond max_distance", "brwn xqzvjk", 1, false, 2, 2, []spellSuggestion{{"brwn", "brown", 1}}},
+		{"wider max_distance", "quikcly", 3, false, 1, 1, []spellSuggestion{{"quikcly", "quick", 3}}},
+		{"no letters", "123 !?", 0, false, 0, 0, []spellSuggestion{}},
+		{"negative max_distance", "brwn", -1, true, 0, 0, nil},
+		{"max_distance too large", "brwn", maxSpellcheckDistance + 1, true, 0, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := spellcheckOperation(tt.text, tt.maxDistance, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result["tokens_checked"] != tt.checked || result["unknown_tokens"] != tt.unknown {
+				t.Errorf("tokens_checked = %v, unknown_tokens = %v, want %d and %d", result["tokens_checked"], result["unknown_tokens"], tt.checked, tt.unknown)
+			}
+			if got := result["suggestions"]; !reflect.DeepEqual(got, tt.suggestions) {
+				t.Errorf("suggestions = %v, want %v", got, tt.suggestions)
+			}
+		})
+	}
+
+	result := gin.H{}
+	if err := spellcheckOperation(strings.Repeat("the ", maxSpellcheckTokens+1), 0, result); err != nil {
+		t.Fatal(err)
+	}
+	if result["tokens_checked"] != maxSpellcheckTokens || result["tokens_truncated"] != true {
+		t.Errorf("tokens_checked = %v, tokens_truncated = %v, want %d and true", result["tokens_checked"], result["tokens_truncated"], maxSpellcheckTokens)
+	}
+}
+
+func TestReadabilityOperation(t *testing.T) {
+	tests := []struct {
+		name                        string
+		text                        string
+		wantErr                     bool
+		sentences, words, syllables int
+	}{
+		{"short sentences", "The cat sat. The dog ran.", false, 2, 6, 6},
+		{"silent e and le", "Make the table enjoyable!", false, 1, 4, 7},
+		{"punctuation runs", "Wait... what?!", false, 2, 2, 2},
+		{"no terminator", "hello world", false, 1, 2, 3},
+		{"apostrophes", "It's Dave's.", false, 1, 2, 3},
+		{"empty", "", true, 0, 0, 0},
+		{"no words", "123 ... 456?", true, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := readabilityOperation(tt.text, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result["sentences"] != tt.sentences || result["words"] != tt.words || result["syllables"] != tt.syllables {
+				t.Errorf("sentences = %v, words = %v, syllables = %v, want %d, %d, %d",
+					result["sentences"], result["words"], result["syllables"], tt.sentences, tt.words, tt.syllables)
+			}
+		})
+	}
+
+	// 3 words per sentence, 1 syllable per word
+	result := gin.H{}
+	if err := readabilityOperation("The cat sat. The dog ran.", result); err != nil {
+		t.Fatal(err)
+	}
+	if ease := result["flesch_reading_ease"].(float64); math.Abs(ease-119.19) > 1e-9 {
+		t.Errorf("flesch_reading_ease = %v, want 119.19", ease)
+	}
+	if grade := result["flesch_kincaid_grade"].(float64); math.Abs(grade+2.62) > 1e-9 {
+		t.Errorf("flesch_kincaid_grade = %v, want -2.62", grade)
+	}
+}
+
+func TestFuzzyMatchOperation(t *testing.T) {
+	const text = "The quick brown fox jumps over the lazy dog"
+	tests := []struct {
+		name        string
+		text        string
+		pattern     string
+		maxDistance int
+		wantErr     bool
+		matchCount  int
+		matches     []fuzzyMatch
+	}{
+		{"one edit", text, "Quack", 0, false, 1, []fuzzyMatch{{"quick", 1, 1}}},
+		{"case insensitive", text, "THE", 1, false, 2, []fuzzyMatch{{"The", 0, 0}, {"the", 6, 0}}},
+		{"several matches", text, "fog", 1, false, 2, []fuzzyMatch{{"fox", 3, 1}, {"dog", 8, 1}}},
+		{"no match", text, "zebra", 1, false, 0, []fuzzyMatch{}},
+		{"missing pattern", text, "", 0, true, 0, nil},
+		{"negative max_distance", text, "fox", -1, true, 0, nil},
+		{"max_distance too large", text, "fox", maxSpellcheckDistance + 1, true, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := fuzzyMatchOperation(tt.text, tt.pattern, tt.maxDistance, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result["match_count"] != tt.matchCount {
+				t.Errorf("match_count = %v, want %d", result["match_count"], tt.matchCount)
+			}
+			if got := result["matches"]; !reflect.DeepEqual(got, tt.matches) {
+				t.Errorf("matches = %v, want %v", got, tt.matches)
+			}
+		})
+	}
+
+	// Matches are capped but still counted, and long texts are truncated
+	result := gin.H{}
+	if err := fuzzyMatchOperation(strings.Repeat("a ", maxFuzzyWords+1), "a", 0, result); err != nil {
+		t.Fatal(err)
+	}
+	if result["match_count"] != maxFuzzyWords || len(result["matches"].([]fuzzyMatch)) != maxFuzzyMatches || result["words_truncated"] != true {
+		t.Errorf("match_count = %v, %d matches, words_truncated = %v", result["match_count"], len(result["matches"].([]fuzzyMatch)), result["words_truncated"])
+	}
+}
+
+func TestBoundedLevenshteinMatchesLevenshtein(t *testing.T) {
+	words := []string{"", "a", "fox", "box", "quick", "quack", "kitten", "sitting", "flaw", "lawn", "héllo", "hello"}
+	for _, a := range words {
+		for _, b := range words {
+			want := levenshtein(a, b)
+			for limit := 1; limit <= maxSpellcheckDistance; limit++ {
+				got, ok := boundedLevenshtein([]rune(a), []rune(b), limit)
+				if ok != (want <= limit) || ok && got != want {
+					t.Errorf("boundedLevenshtein(%q, %q, %d) = %d, %v, want distance %d", a, b, limit, got, ok, want)
+				}
+			}
+		}
+	}
+}
+
+func TestRunsOperation(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/strings", handleStringProcessing)
+
+	tests := []struct {
+		name                                 string
+		body                                 string
+		status                               int
+		runs, ascending, descending, longest float64
+	}{
+		{"empty", `{"operation":"runs","text":""}`, http.StatusOK, 0, 0, 0, 0},
+		{"single rune", `{"operation":"runs","text":"x"}`, http.StatusOK, 1, 0, 0, 1},
+		{"ascending", `{"operation":"runs","text":"abc"}`, http.StatusOK, 1, 1, 0, 3},
+		{"descending", `{"operation":"runs","text":"cba"}`, http.StatusOK, 1, 0, 1, 3},
+		{"up then down", `{"operation":"runs","text":"abcba"}`, http.StatusOK, 2, 1, 1, 3},
+		{"equal neighbours", `{"operation":"runs","text":"aaa"}`, http.StatusOK, 3, 0, 0, 1},
+		{"zigzag", `{"operation":"runs","text":"abab"}`, http.StatusOK, 2, 2, 0, 2},
+		{"codepoints", `{"operation":"runs","text":"azé"}`, http.StatusOK, 1, 1, 0, 3},
+		{"text not a string", `{"operation":"runs","text":["abc"]}`, http.StatusBadRequest, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/strings", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			resp := decodeJSON(t, w)
+			if resp["run_count"] != tt.runs || resp["ascending_runs"] != tt.ascending || resp["descending_runs"] != tt.descending || resp["longest_run"] != tt.longest {
+				t.Errorf("run_count = %v, ascending_runs = %v, descending_runs = %v, longest_run = %v, want %v, %v, %v, %v",
+					resp["run_count"], resp["ascending_runs"], resp["descending_runs"], resp["longest_run"], tt.runs, tt.ascending, tt.descending, tt.longest)
+			}
+		})
+	}
+}
+
+func TestBalanceOperation(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/strings", handleStringProcessing)
+
+	tests := []struct {
+		name      string
+		text      string
+		balanced  bool
+		depth     float64
+		imbalance interface{}
+	}{
+		{"empty", "", true, 0, nil},
+		{"nested", "{[()()]}", true, 3, nil},
+		{"with text", "f(a[0], {b})", true, 2, nil},
+		{"wrong closer", "(]", false, 1, float64(1)},
+		{"closer first", ")(", false, 0, float64(0)},
+		{"crossed", "([)]", false, 2, float64(2)},
+		{"left open", "((x)", false, 2, float64(0)},
+		{"rune positions", "é€(", false, 1, float64(2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/strings", fmt.Sprintf(`{"operation":"balance","text":%q}`, tt.text))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			resp := decodeJSON(t, w)
+			if resp["balanced"] != tt.balanced || resp["max_depth"] != tt.depth || resp["imbalance_position"] != tt.imbalance {
+				t.Errorf("balanced = %v, max_depth = %v, imbalance_position = %v, want %v, %v, %v",
+					resp["balanced"], resp["max_depth"], resp["imbalance_position"], tt.balanced, tt.depth, tt.imbalance)
+			}
+		})
+	}
+
+	if w := doRequest(r, http.MethodPost, "/process/strings", `{"operation":"balance","text":{}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("text not a string: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSimHashOperation(t *testing.T) {
+	// A single shingle's fingerprint is its own FNV-1a hash
+	fnvHex := func(s string) string {
+		h := fnv.New64a()
+		h.Write([]byte(s))
+		return fmt.Sprintf("%016x", h.Sum64())
+	}
+
+	tests := []struct {
+		name        string
+		text, text2 string
+		k           int
+		wantErr     bool
+		fingerprint string
+		distance    interface{}
+	}{
+		{"one word", "Benchmark", "", 1, false, fnvHex("benchmark"), nil},
+		{"one shingle", "quick brown", "", 0, false, fnvHex("quick brown"), nil},
+		{"shorter than k", "quick brown", "", 5, false, fnvHex("quick brown"), nil},
+		{"identical ignoring case", "The Quick Brown Fox", "the quick  brown fox", 0, false, "", 0},
+		{"negative shingle_size", "abc", "", -1, true, "", nil},
+		{"shingle_size too large", "abc", "", maxShingleSize + 1, true, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := simHashOperation(tt.text, tt.text2, tt.k, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.fingerprint != "" && result["fingerprint"] != tt.fingerprint {
+				t.Errorf("fingerprint = %v, want %s", result["fingerprint"], tt.fingerprint)
+			}
+			if result["hamming_distance"] != tt.distance {
+				t.Errorf("hamming_distance = %v, want %v", result["hamming_distance"], tt.distance)
+			}
+		})
+	}
+
+	// Near-duplicates land closer than unrelated texts
+	distance := func(a, b string) int {
+		result := gin.H{}
+		if err := simHashOperation(a, b, 1, result); err != nil {
+			t.Fatal(err)
+		}
+		return result["hamming_distance"].(int)
+	}
+	near := distance(standardText, standardText+" cat")
+	far := distance(standardText, "lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod")
+	if near >= far {
+		t.Errorf("near-duplicate distance %d, unrelated distance %d", near, far)
+	}
+}
+
+func TestTfidfOperation(t *testing.T) {
+	idf := func(n, df float64) float64 { return math.Log((1+n)/(1+df)) + 1 }
+
+	tests := []struct {
+		name      string
+		text      string
+		delimiter string
+		docs      int
+		vocab     int
+		top       [][]termScore
+	}{
+		{"blank lines", "a a B\n \t\nb, c!", "", 2, 3, [][]termScore{
+			{{"a", 2.0 / 3 * idf(2, 1)}, {"b", 1.0 / 3}},
+			{{"c", 0.5 * idf(2, 1)}, {"b", 0.5}},
+		}},
+		{"custom delimiter", "x y|y||", "|", 2, 2, [][]termScore{
+			{{"x", 0.5 * idf(2, 1)}, {"y", 0.5}},
+			{{"y", 1}},
+		}},
+		{"ties sorted by term", "d c b a", "", 1, 4, [][]termScore{
+			{{"a", 0.25}, {"b", 0.25}, {"c", 0.25}, {"d", 0.25}},
+		}},
+		{"no terms", "!!\n\n??", "", 0, 0, [][]termScore{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			tfidfOperation(tt.text, tt.delimiter, result)
+			if result["document_count"] != tt.docs || result["vocabulary_size"] != tt.vocab {
+				t.Errorf("document_count = %v, vocabulary_size = %v, want %d and %d", result["document_count"], result["vocabulary_size"], tt.docs, tt.vocab)
+			}
+			top := result["top_terms"].([][]termScore)
+			if len(top) != len(tt.top) {
+				t.Fatalf("top_terms = %v, want %v", top, tt.top)
+			}
+			for i := range top {
+				if len(top[i]) != len(tt.top[i]) {
+					t.Fatalf("document %d: top_terms = %v, want %v", i, top[i], tt.top[i])
+				}
+				for j, want := range tt.top[i] {
+					if got := top[i][j]; got.Term != want.Term || math.Abs(got.Score-want.Score) > 1e-12 {
+						t.Errorf("document %d term %d = %v, want %v", i, j, got, want)
+					}
+				}
+			}
+		})
+	}
+
+	// Output is capped per document and in documents, not in the counts
+	result := gin.H{}
+	tfidfOperation(strings.Repeat("a b c d e f g\n\n", maxTfidfDocuments+5), "", result)
+	top := result["top_terms"].([][]termScore)
+	if result["document_count"] != maxTfidfDocuments+5 || len(top) != maxTfidfDocuments || len(top[0]) != tfidfTopTerms {
+		t.Errorf("document_count = %v, %d documents and %d terms reported", result["document_count"], len(top), len(top[0]))
+	}
+
+	r := gin.New()
+	r.POST("/process/strings", handleStringProcessing)
+	if w := doRequest(r, http.MethodPost, "/process/strings", `{"operation":"tfidf","text":"a","delimiter":7}`); w.Code != http.StatusBadRequest {
+		t.Errorf("delimiter not a string: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSuffixAutomatonOperation(t *testing.T) {
+	// Distinct non-empty substrings, counted directly
+	distinct := func(text string) int {
+		runes := []rune(text)
+		set := make(map[string]bool)
+		for i := range runes {
+			for j := i + 1; j <= len(runes); j++ {
+				set[string(runes[i:j])] = true
+			}
+		}
+		return len(set)
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+		states  int
+	}{
+		{"empty", "", false, 1},
+		{"repeated rune", "aaaa", false, 5},
+		{"abcbc", "abcbc", false, 8},
+		{"banana", "banana", false, 0},
+		{"runes", "héhé€", false, 0},
+		{"sentence", "the quick brown fox jumps over the lazy dog", false, 0},
+		{"too long", strings.Repeat("a", maxSuffixAutomatonRunes+1), true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := suffixAutomatonOperation(tt.text, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := distinct(tt.text); result["distinct_substrings"] != want {
+				t.Errorf("distinct_substrings = %v, want %d", result["distinct_substrings"], want)
+			}
+			// At most 2n-1 states besides the root, and 3n-4 transitions
+			states, transitions, n := result["states"].(int), result["transitions"].(int), len([]rune(tt.text))
+			if tt.states != 0 && states != tt.states {
+				t.Errorf("states = %d, want %d", states, tt.states)
+			}
+			if n >= 3 && (states > 2*n || transitions > 3*n-4) {
+				t.Errorf("states = %d, transitions = %d for %d runes", states, transitions, n)
+			}
+		})
+	}
+}
+
+func TestCSVStatsOperation(t *testing.T) {
+	const ragged = "a,b\n1,2\n3\n4,5,6\n"
+	tests := []struct {
+		name      string
+		text      string
+		tolerance int
+		wantErr   bool
+		rows      int
+		ragged    int
+	}{
+		{"rectangular", "a,b\n1,x\n3,y\n", 0, false, 2, 0},
+		{"ragged within tolerance", ragged, 2, false, 3, 2},
+		{"ragged beyond tolerance", ragged, 1, true, 0, 0},
+		{"negative tolerance", "a,b\n1,2\n", -1, true, 0, 0},
+		{"no header", "", 0, true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := csvStatsOperation(tt.text, tt.tolerance, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result["row_count"] != tt.rows || result["ragged_rows"] != tt.ragged {
+				t.Errorf("row_count = %v, ragged_rows = %v, want %d and %d", result["row_count"], result["ragged_rows"], tt.rows, tt.ragged)
+			}
+		})
+	}
+
+	r := gin.New()
+	r.POST("/process/strings", handleStringProcessing)
+	body := `{"operation":"csv_stats","text":"a,b\n1,2\n","ragged_tolerance":-1}`
+	if w := doRequest(r, http.MethodPost, "/process/strings", body); w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+	}
+}
+
+func TestBoyerMooreOperation(t *testing.T) {
+	// Every match, overlapping ones included, found with strings.Index
+	indexAll := func(text, pattern string) []int {
+		positions := []int{}
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], pattern)
+			if i < 0 {
+				return positions
+			}
+			positions = append(positions, offset+i)
+			offset += i + 1
+		}
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		pattern string
+		wantErr bool
+	}{
+		{"single match", "the quick brown fox", "brown", false},
+		{"overlapping runs", "aaaaaaa", "aaa", false},
+		{"overlapping periods", "abababababa", "abab", false},
+		{"good suffix", "acabacacabacabacab", "cabacab", false},
+		{"missing", "the quick brown fox", "wolf", false},
+		{"pattern longer than text", "fox", "foxes", false},
+		{"pattern is the text", "fox", "fox", false},
+		{"multibyte pattern", "café, caffè, café", "café", false},
+		{"multibyte text", "🌍a🌍ab🌍", "a🌍", false},
+		{"empty pattern", "fox", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := gin.H{}
+			err := boyerMooreOperation(tt.text, tt.pattern, result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want := indexAll(tt.text, tt.pattern)
+			if !reflect.DeepEqual(result["positions"], want) {
+				t.Errorf("positions = %v, want %v", result["positions"], want)
+			}
+			if result["match_count"] != len(want) {
+				t.Errorf("match_count = %v, want %d", result["match_count"], len(want))
+			}
+		})
+	}
+
+	t.Run("truncated positions", func(t *testing.T) {
+		result := gin.H{}
+		if err := boyerMooreOperation(strings.Repeat("ab", 2*maxBoyerMooreMatches), "ab", result); err != nil {
+			t.Fatal(err)
+		}
+		if result["match_count"] != 2*maxBoyerMooreMatches || result["positions_truncated"] != true {
+			t.Errorf("match_count = %v, positions_truncated = %v", result["match_count"], result["positions_truncated"])
+		}
+		if positions, _ := result["positions"].([]int); len(positions) != maxBoyerMooreMatches {
+			t.Errorf("%d positions, want %d", len(positions), maxBoyerMooreMatches)
+		}
+	})
+}
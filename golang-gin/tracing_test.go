@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+func TestTraceRequests(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	setConfig(t, &tracer, provider.Tracer("benchmark-go"))
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagator) })
+
+	r := gin.New()
+	r.Use(traceRequests())
+	r.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	const parentTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		span        string
+		status      int
+		parent      bool
+	}{
+		{"route", "/items/42", "", "GET /items/:id", http.StatusOK, false},
+		{"continues a trace", "/items/42", "00-" + parentTrace + "-00f067aa0ba902b7-01", "GET /items/:id", http.StatusOK, true},
+		{"malformed traceparent", "/items/42", "00-nope-01", "GET /items/:id", http.StatusOK, false},
+		{"server error", "/fail", "", "GET /fail", http.StatusInternalServerError, false},
+		{"unmatched", "/nope", "", "GET unmatched", http.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			if len(spans) == 0 {
+				t.Fatal("no span recorded")
+			}
+			span := spans[len(spans)-1]
+			if span.Name() != tt.span {
+				t.Errorf("span name = %q, want %q", span.Name(), tt.span)
+			}
+			if got := span.Parent().IsValid(); got != tt.parent {
+				t.Errorf("has parent = %v, want %v", got, tt.parent)
+			}
+			if tt.parent && span.SpanContext().TraceID().String() != parentTrace {
+				t.Errorf("trace id = %s, want %s", span.SpanContext().TraceID(), parentTrace)
+			}
+			var status int64
+			for _, attr := range span.Attributes() {
+				if attr.Key == semconv.HTTPResponseStatusCodeKey {
+					status = attr.Value.AsInt64()
+				}
+			}
+			if status != int64(tt.status) {
+				t.Errorf("status attribute = %d, want %d", status, tt.status)
+			}
+			if isError := span.Status().Code == codes.Error; isError != (tt.status >= 500) {
+				t.Errorf("span status = %v for HTTP %d", span.Status().Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// bindingErrorResponse turns an error from binding a JSON body into obj into
+// a 400 response body. Validation and type errors are listed per JSON field
+// under "fields", e.g. {"name": "name is required"}; anything else (such as
+// malformed JSON) is reported as is.
+func bindingErrorResponse(err error, obj interface{}) gin.H {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make(map[string]string, len(validationErrs))
+		for _, fe := range validationErrs {
+			name := jsonFieldName(obj, fe.StructField())
+			fields[name] = validationReason(name, fe)
+		}
+		return gin.H{"error": "Invalid request body", "fields": fields}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return gin.H{
+			"error":  "Invalid request body",
+			"fields": map[string]string{typeErr.Field: fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type)},
+		}
+	}
+	return gin.H{"error": err.Error()}
+}
+
+// jsonFieldName returns the JSON key of obj's struct field, falling back to
+// the Go name when the field has no json tag.
+func jsonFieldName(obj interface{}, structField string) string {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return structField
+	}
+	f, ok := t.FieldByName(structField)
+	if !ok {
+		return structField
+	}
+	if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return structField
+}
+
+func validationReason(name string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return name + " is required"
+	case "email":
+		return name + " must be a valid email address"
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", name, fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", name, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", name, fe.Param())
+	default:
+		return fmt.Sprintf("%s failed the %s check", name, fe.Tag())
+	}
+}
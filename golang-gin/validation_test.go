@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBindingErrorResponse(t *testing.T) {
+	r := gin.New()
+	r.POST("/process/normal", handleNormalWork)
+
+	tests := []struct {
+		name   string
+		body   string
+		error  string
+		fields map[string]string
+	}{
+		{
+			"missing name",
+			`{"birthdate":"1990-05-15","email":"ada@example.com"}`,
+			"Invalid request body",
+			map[string]string{"name": "name is required"},
+		},
+		{
+			"several missing",
+			`{"name":"Ada"}`,
+			"Invalid request body",
+			map[string]string{"birthdate": "birthdate is required", "email": "email is required"},
+		},
+		{
+			"wrong type",
+			`{"name":42,"birthdate":"1990-05-15","email":"ada@example.com"}`,
+			"Invalid request body",
+			map[string]string{"name": "name must be of type string"},
+		},
+		{"malformed JSON", `{"name":`, "unexpected EOF", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/process/normal", tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+			var resp struct {
+				Error  string            `json:"error"`
+				Fields map[string]string `json:"fields"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error != tt.error || !reflect.DeepEqual(resp.Fields, tt.fields) {
+				t.Errorf("response = %s, want error %q with fields %v", w.Body, tt.error, tt.fields)
+			}
+		})
+	}
+}
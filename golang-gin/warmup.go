@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Most passes over the workloads a single /warmup call may make
+const maxWarmupIterations = 100
+
+type WarmupRequest struct {
+	// Passes over the workloads (default 3)
+	Iterations int `json:"iterations"`
+
+	// Standard workload names to run; all of them when empty
+	Workloads []string `json:"workloads"`
+}
+
+// warmingUp is held while a warmup runs, so overlapping calls cannot clear
+// the readiness reason while another is still going.
+var warmingUp sync.Mutex
+
+// warmupResult summarises one workload's warmup runs.
+type warmupResult struct {
+	Name         string  `json:"name"`
+	Failures     int     `json:"failures"`
+	TotalSeconds float64 `json:"total_seconds"`
+}
+
+// handleWarmup runs the standard workloads in-process a few times so the
+// allocator, GC pacing and CPU caches settle before a measured run. The
+// workload responses are discarded. /health/ready reports not ready while
+// it runs, and a second call made meanwhile gets 409.
+func handleWarmup(handler http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req WarmupRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if req.Iterations == 0 {
+			req.Iterations = 3
+		}
+		if req.Iterations < 1 || req.Iterations > maxWarmupIterations {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("iterations must be between 1 and %d", maxWarmupIterations)})
+			return
+		}
+
+		workloads := standardWorkloads
+		if len(req.Workloads) > 0 {
+			workloads = make([]workload, 0, len(req.Workloads))
+			for _, name := range req.Workloads {
+				w, ok := standardWorkload(name)
+				if !ok {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown workload: " + name})
+					return
+				}
+				workloads = append(workloads, w)
+			}
+		}
+
+		if !warmingUp.TryLock() {
+			c.JSON(http.StatusConflict, gin.H{"error": "A warmup is already running"})
+			return
+		}
+		defer warmingUp.Unlock()
+
+		setNotReady("warming up")
+		defer notReadyReason.CompareAndSwap("warming up", "")
+
+		start := time.Now()
+		results := make([]warmupResult, len(workloads))
+		failures := 0
+		for i, w := range workloads {
+			results[i].Name = w.Name
+			var total time.Duration
+			for j := 0; j < req.Iterations; j++ {
+				status, elapsed := runWorkload(handler, w)
+				total += elapsed
+				if status != http.StatusOK {
+					results[i].Failures++
+				}
+			}
+			results[i].TotalSeconds = total.Seconds()
+			failures += results[i].Failures
+		}
+		elapsed := time.Since(start)
+		logf("info", "warmup: %d workloads x %d iterations took %s", len(workloads), req.Iterations, elapsed)
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":        "warmed up",
+			"iterations":    req.Iterations,
+			"requests":      len(workloads) * req.Iterations,
+			"failures":      failures,
+			"workloads":     results,
+			"total_seconds": elapsed.Seconds(),
+			"service":       "Go Gin",
+		})
+	}
+}
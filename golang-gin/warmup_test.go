@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWarmup(t *testing.T) {
+	t.Cleanup(func() { setNotReady("") })
+	r := gin.New()
+	r.POST("/warmup", handleWarmup(newReplayRouter()))
+
+	tests := []struct {
+		name      string
+		body      string
+		status    int
+		workloads int
+	}{
+		{"every workload once", `{"iterations":1}`, http.StatusOK, len(standardWorkloads)},
+		{"chosen workloads", `{"iterations":2,"workloads":["hello","normal"]}`, http.StatusOK, 2},
+		{"unknown workload", `{"workloads":["nope"]}`, http.StatusBadRequest, 0},
+		{"too many iterations", `{"iterations":101}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/warmup", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Status     string         `json:"status"`
+				Iterations int            `json:"iterations"`
+				Requests   int            `json:"requests"`
+				Failures   int            `json:"failures"`
+				Workloads  []warmupResult `json:"workloads"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Status != "warmed up" || len(resp.Workloads) != tt.workloads || resp.Requests != tt.workloads*resp.Iterations {
+				t.Errorf("summary = %s", w.Body)
+			}
+			for _, result := range resp.Workloads {
+				if result.Failures != 0 {
+					t.Errorf("workload %s failed %d times", result.Name, result.Failures)
+				}
+			}
+		})
+	}
+
+	// Readiness is restored afterwards
+	if reason, _ := notReadyReason.Load().(string); reason != "" {
+		t.Errorf("still not ready after warmup: %s", reason)
+	}
+}
+
+func TestOverlappingWarmups(t *testing.T) {
+	t.Cleanup(func() { setNotReady("") })
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	r := gin.New()
+	r.POST("/warmup", handleWarmup(blocking))
+
+	const body = `{"iterations":1,"workloads":["hello"]}`
+	first := make(chan int)
+	go func() { first <- doRequest(r, http.MethodPost, "/warmup", body).Code }()
+	<-started
+
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"while one runs", http.StatusConflict},
+		{"again while one runs", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doRequest(r, http.MethodPost, "/warmup", body); w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if reason, _ := notReadyReason.Load().(string); reason != "warming up" {
+				t.Errorf("readiness reason = %q while warming up", reason)
+			}
+		})
+	}
+
+	close(release)
+	if status := <-first; status != http.StatusOK {
+		t.Errorf("first warmup status = %d, want %d", status, http.StatusOK)
+	}
+	if reason, _ := notReadyReason.Load().(string); reason != "" {
+		t.Errorf("still not ready after warmup: %s", reason)
+	}
+	if w := doRequest(r, http.MethodPost, "/warmup", body); w.Code != http.StatusOK {
+		t.Errorf("status after the first finished = %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/net/websocket"
+)
+
+// A connection that sends nothing for this long is closed, so abandoned
+// clients don't hold a goroutine forever.
+const websocketIdleTimeout = 60 * time.Second
+
+var websocketConnections = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "websocket_connections",
+	Help: "WebSocket connections currently open on /ws.",
+})
+
+// echoMessage is one WebSocket message, kept with its frame type so text
+// is echoed as text and binary as binary.
+type echoMessage struct {
+	data        []byte
+	payloadType byte
+}
+
+var echoCodec = websocket.Codec{
+	Marshal: func(v interface{}) ([]byte, byte, error) {
+		msg := v.(*echoMessage)
+		return msg.data, msg.payloadType, nil
+	},
+	Unmarshal: func(data []byte, payloadType byte, v interface{}) error {
+		msg := v.(*echoMessage)
+		msg.data, msg.payloadType = data, payloadType
+		return nil
+	},
+}
+
+var echoServer = websocket.Server{
+	Handshake: checkWebSocketOrigin,
+	Handler:   echoMessages,
+}
+
+// handleWebSocket upgrades GET /ws to a WebSocket that echoes back every
+// message it receives.
+func handleWebSocket(c *gin.Context) {
+	echoServer.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkWebSocketOrigin accepts clients that send no Origin (load
+// generators), same-host pages, and origins allowed by -cors-origins.
+func checkWebSocketOrigin(cfg *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if u.Host == r.Host {
+		return nil
+	}
+	for _, allowed := range strings.Split(config.CORSOrigins, ",") {
+		if allowed = strings.TrimSpace(allowed); allowed == "*" || allowed == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %q not allowed", origin)
+}
+
+func echoMessages(ws *websocket.Conn) {
+	websocketConnections.Inc()
+	defer websocketConnections.Dec()
+	defer ws.Close()
+
+	start := time.Now()
+	messages := 0
+	for {
+		ws.SetReadDeadline(time.Now().Add(websocketIdleTimeout))
+		var msg echoMessage
+		if err := echoCodec.Receive(ws, &msg); err != nil {
+			if !errors.Is(err, io.EOF) {
+				logf("debug", "ws: closing after %d messages: %v", messages, err)
+			}
+			break
+		}
+		if err := echoCodec.Send(ws, &msg); err != nil {
+			logf("debug", "ws: send failed after %d messages: %v", messages, err)
+			break
+		}
+		messages++
+	}
+	logf("debug", "ws: connection closed after %d messages in %s", messages, time.Since(start))
+}
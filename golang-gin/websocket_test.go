@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocketEcho(t *testing.T) {
+	r := gin.New()
+	r.Use(serverTimingHeader())
+	r.GET("/ws", handleWebSocket)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	ws, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	tests := []struct {
+		name string
+		msg  echoMessage
+	}{
+		{"text", echoMessage{data: []byte("hello"), payloadType: websocket.TextFrame}},
+		{"binary", echoMessage{data: []byte{0, 1, 2, 255}, payloadType: websocket.BinaryFrame}},
+		{"empty text", echoMessage{data: []byte{}, payloadType: websocket.TextFrame}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := echoCodec.Send(ws, &tt.msg); err != nil {
+				t.Fatal(err)
+			}
+			var echo echoMessage
+			if err := echoCodec.Receive(ws, &echo); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(echo.data, tt.msg.data) || echo.payloadType != tt.msg.payloadType {
+				t.Errorf("echo = %v (type %d), want %v (type %d)", echo.data, echo.payloadType, tt.msg.data, tt.msg.payloadType)
+			}
+		})
+	}
+}
+
+func TestWebSocketRejectsForeignOrigin(t *testing.T) {
+	setConfig(t, &config.CORSOrigins, "http://dash.local")
+	r := gin.New()
+	r.GET("/ws", handleWebSocket)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	tests := []struct {
+		origin string
+		ok     bool
+	}{
+		{server.URL, true},
+		{"http://dash.local", true},
+		{"http://evil.local", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			ws, err := websocket.Dial(url, "", tt.origin)
+			if err == nil {
+				ws.Close()
+			}
+			if (err == nil) != tt.ok {
+				t.Errorf("dial error = %v, want success %v", err, tt.ok)
+			}
+		})
+	}
+}